@@ -0,0 +1,361 @@
+// Package config loads the server settings from an optional YAML file and the
+// environment, and validates them at startup.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the server. Load fills it from, in order of
+// precedence, the environment, the YAML file named by CONFIG_FILE and the
+// defaults below.
+type Config struct {
+	// ListenAddr is the HTTP listen address (LISTEN_ADDR, or :$PORT).
+	ListenAddr string `yaml:"listenAddr"`
+	// CORSOrigins are the browser origins allowed to call the API (CORS_ORIGINS, comma-separated).
+	CORSOrigins []string `yaml:"corsOrigins"`
+	// LogLevel is debug, info, warn or error (LOG_LEVEL).
+	LogLevel string `yaml:"logLevel"`
+	// AdminToken guards /api/admin/*, which is disabled while it is empty (ADMIN_TOKEN).
+	AdminToken string `yaml:"adminToken"`
+	// MigrateOnStart applies pending migrations at startup (MIGRATE_ON_START).
+	MigrateOnStart bool `yaml:"migrateOnStart"`
+	// ShutdownTimeout bounds how long a stopping server waits for requests and background work (SHUTDOWN_TIMEOUT).
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// ShutdownDelay is how long a stopping server keeps serving while /readyz fails,
+	// so load balancers stop routing to it first (SHUTDOWN_DELAY).
+	ShutdownDelay time.Duration `yaml:"shutdownDelay"`
+
+	Database  Database  `yaml:"database"`
+	Auth      Auth      `yaml:"auth"`
+	SMTP      SMTP      `yaml:"smtp"`
+	SMS       SMS       `yaml:"sms"`
+	Scheduler Scheduler `yaml:"scheduler"`
+}
+
+// Database configures the Postgres connection pool.
+type Database struct {
+	URL             string        `yaml:"url"`             // DATABASE_URL
+	MaxOpenConns    int           `yaml:"maxOpenConns"`    // DB_MAX_OPEN_CONNS, 0 for no limit
+	MaxIdleConns    int           `yaml:"maxIdleConns"`    // DB_MAX_IDLE_CONNS
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"` // DB_CONN_MAX_LIFETIME, 0 to keep connections
+}
+
+// Auth configures JWT verification. Keys come from one of, in order of
+// precedence, HS256Secret, PublicKeyFile or the JWKS endpoint, which defaults
+// to <Issuer>/.well-known/jwks.json.
+type Auth struct {
+	Issuer        string `yaml:"issuer"`        // AUTH_ISSUER
+	Audience      string `yaml:"audience"`      // AUTH_AUDIENCE
+	JWKSURL       string `yaml:"jwksUrl"`       // AUTH_JWKS_URL
+	OrgClaim      string `yaml:"orgClaim"`      // AUTH_ORG_CLAIM
+	RoleClaim     string `yaml:"roleClaim"`     // AUTH_ROLE_CLAIM
+	HS256Secret   string `yaml:"hs256Secret"`   // AUTH_HS256_SECRET, development and tests only
+	PublicKeyFile string `yaml:"publicKeyFile"` // AUTH_PUBLIC_KEY_FILE
+	// DefaultRole is given to members without a local override whose role claim is
+	// missing, "org:member" or unknown (AUTH_DEFAULT_ROLE). It defaults to responder,
+	// what every member could do before roles existed; set viewer for read-only members.
+	DefaultRole string `yaml:"defaultRole"`
+}
+
+// SMTP configures outgoing email, which is disabled while Host is empty.
+type SMTP struct {
+	Host   string `yaml:"host"`   // SMTP_HOST
+	Port   int    `yaml:"port"`   // SMTP_PORT
+	User   string `yaml:"user"`   // SMTP_USER
+	Pass   string `yaml:"pass"`   // SMTP_PASS
+	Sender string `yaml:"sender"` // SMTP_SENDER
+	// NotifyTo receive every notification immediately (SMTP_NOTIFY_TO, comma-separated).
+	NotifyTo []string `yaml:"notifyTo"`
+}
+
+// Enabled reports whether email can be sent.
+func (s SMTP) Enabled() bool {
+	return s.Host != ""
+}
+
+// SMS configures text messages and voice calls through the Twilio REST API,
+// which are disabled while AccountSID is empty.
+type SMS struct {
+	APIURL     string `yaml:"apiUrl"`     // SMS_API_URL
+	AccountSID string `yaml:"accountSid"` // SMS_ACCOUNT_SID
+	AuthToken  string `yaml:"authToken"`  // SMS_AUTH_TOKEN
+	From       string `yaml:"from"`       // SMS_FROM
+}
+
+// Enabled reports whether messages can be sent.
+func (s SMS) Enabled() bool {
+	return s.AccountSID != ""
+}
+
+// Scheduler configures the background jobs.
+type Scheduler struct {
+	DigestInterval        time.Duration `yaml:"digestInterval"`        // DIGEST_INTERVAL
+	EventLogPurgeInterval time.Duration `yaml:"eventLogPurgeInterval"` // EVENT_LOG_PURGE_INTERVAL
+	ArchivePurgeInterval  time.Duration `yaml:"archivePurgeInterval"`  // ARCHIVE_PURGE_INTERVAL
+	// ArchiveRetentionDays is how long archived services and incidents are kept (ARCHIVE_RETENTION_DAYS).
+	ArchiveRetentionDays int `yaml:"archiveRetentionDays"`
+}
+
+// ArchiveRetention returns ArchiveRetentionDays as a duration.
+func (s Scheduler) ArchiveRetention() time.Duration {
+	return time.Duration(s.ArchiveRetentionDays) * 24 * time.Hour
+}
+
+// Default returns the settings used where neither the file nor the
+// environment sets a value.
+func Default() Config {
+	return Config{
+		ListenAddr:      ":8080",
+		CORSOrigins:     []string{"http://localhost:3000", "https://clearstatus.vercel.app"},
+		LogLevel:        "info",
+		MigrateOnStart:  true,
+		ShutdownTimeout: 30 * time.Second,
+		ShutdownDelay:   5 * time.Second,
+		Database: Database{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+		Auth: Auth{
+			OrgClaim:    "org_id",
+			RoleClaim:   "org_role",
+			DefaultRole: "responder",
+		},
+		SMTP: SMTP{Port: 587},
+		SMS:  SMS{APIURL: "https://api.twilio.com"},
+		Scheduler: Scheduler{
+			DigestInterval:        time.Minute,
+			EventLogPurgeInterval: 10 * time.Minute,
+			ArchivePurgeInterval:  time.Hour,
+			ArchiveRetentionDays:  90,
+		},
+	}
+}
+
+// Load reads the configuration and validates it. The error lists every
+// invalid setting, one per line.
+func Load() (*Config, error) {
+	cfg := Default()
+	var errs []error
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+		slog.Info("loaded config file", "path", path)
+	}
+	for _, v := range cfg.envVars() {
+		s, ok := os.LookupEnv(v.name)
+		if !ok || s == "" {
+			continue
+		}
+		if err := v.set(strings.TrimSpace(s)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.name, err))
+		}
+	}
+	if port := os.Getenv("PORT"); port != "" && os.Getenv("LISTEN_ADDR") == "" {
+		cfg.ListenAddr = ":" + port
+	}
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return &cfg, nil
+}
+
+func (cfg *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// envVar overrides one setting from the environment.
+type envVar struct {
+	name string
+	set  func(string) error
+}
+
+func (cfg *Config) envVars() []envVar {
+	return []envVar{
+		{"LISTEN_ADDR", str(&cfg.ListenAddr)},
+		{"CORS_ORIGINS", list(&cfg.CORSOrigins)},
+		{"LOG_LEVEL", str(&cfg.LogLevel)},
+		{"ADMIN_TOKEN", str(&cfg.AdminToken)},
+		{"MIGRATE_ON_START", boolean(&cfg.MigrateOnStart)},
+		{"SHUTDOWN_TIMEOUT", duration(&cfg.ShutdownTimeout)},
+		{"SHUTDOWN_DELAY", duration(&cfg.ShutdownDelay)},
+
+		{"DATABASE_URL", str(&cfg.Database.URL)},
+		{"DB_MAX_OPEN_CONNS", integer(&cfg.Database.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", integer(&cfg.Database.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", duration(&cfg.Database.ConnMaxLifetime)},
+
+		{"AUTH_ISSUER", str(&cfg.Auth.Issuer)},
+		{"AUTH_AUDIENCE", str(&cfg.Auth.Audience)},
+		{"AUTH_JWKS_URL", str(&cfg.Auth.JWKSURL)},
+		{"AUTH_ORG_CLAIM", str(&cfg.Auth.OrgClaim)},
+		{"AUTH_ROLE_CLAIM", str(&cfg.Auth.RoleClaim)},
+		{"AUTH_HS256_SECRET", str(&cfg.Auth.HS256Secret)},
+		{"AUTH_PUBLIC_KEY_FILE", str(&cfg.Auth.PublicKeyFile)},
+		{"AUTH_DEFAULT_ROLE", str(&cfg.Auth.DefaultRole)},
+
+		{"SMTP_HOST", str(&cfg.SMTP.Host)},
+		{"SMTP_PORT", integer(&cfg.SMTP.Port)},
+		{"SMTP_USER", str(&cfg.SMTP.User)},
+		{"SMTP_PASS", str(&cfg.SMTP.Pass)},
+		{"SMTP_SENDER", str(&cfg.SMTP.Sender)},
+		{"SMTP_NOTIFY_TO", list(&cfg.SMTP.NotifyTo)},
+
+		{"SMS_API_URL", str(&cfg.SMS.APIURL)},
+		{"SMS_ACCOUNT_SID", str(&cfg.SMS.AccountSID)},
+		{"SMS_AUTH_TOKEN", str(&cfg.SMS.AuthToken)},
+		{"SMS_FROM", str(&cfg.SMS.From)},
+
+		{"DIGEST_INTERVAL", duration(&cfg.Scheduler.DigestInterval)},
+		{"EVENT_LOG_PURGE_INTERVAL", duration(&cfg.Scheduler.EventLogPurgeInterval)},
+		{"ARCHIVE_PURGE_INTERVAL", duration(&cfg.Scheduler.ArchivePurgeInterval)},
+		{"ARCHIVE_RETENTION_DAYS", integer(&cfg.Scheduler.ArchiveRetentionDays)},
+	}
+}
+
+func str(p *string) func(string) error {
+	return func(s string) error { *p = s; return nil }
+}
+
+func list(p *[]string) func(string) error {
+	return func(s string) error {
+		*p = nil
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}
+}
+
+func integer(p *int) func(string) error {
+	return func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("must be a whole number, got %q", s)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func boolean(p *bool) func(string) error {
+	return func(s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", s)
+		}
+		*p = b
+		return nil
+	}
+}
+
+func duration(p *time.Duration) func(string) error {
+	return func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 5m, got %q", s)
+		}
+		*p = d
+		return nil
+	}
+}
+
+// validate returns one error per invalid setting, named after its environment variable.
+func (cfg *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, name, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]interface{}{name}, args...)...))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(cfg.ListenAddr)
+	check(err == nil && port != "", "LISTEN_ADDR", "must be host:port or :port, got %q", cfg.ListenAddr)
+	check(len(cfg.CORSOrigins) > 0, "CORS_ORIGINS", "must list at least one origin")
+	for _, origin := range cfg.CORSOrigins {
+		check(isOrigin(origin), "CORS_ORIGINS", "%q must be a scheme and host such as https://status.example.com", origin)
+	}
+	var level slog.Level
+	check(level.UnmarshalText([]byte(cfg.LogLevel)) == nil, "LOG_LEVEL", "must be debug, info, warn or error, got %q", cfg.LogLevel)
+	check(cfg.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT", "must be positive")
+	check(cfg.ShutdownDelay >= 0, "SHUTDOWN_DELAY", "must not be negative")
+
+	db := cfg.Database
+	check(db.URL != "", "DATABASE_URL", "is required")
+	check(db.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative")
+	check(db.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns, "DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d)", db.MaxOpenConns)
+	check(db.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative")
+
+	auth := cfg.Auth
+	check(auth.HS256Secret != "" || auth.PublicKeyFile != "" || auth.JWKSURL != "" || auth.Issuer != "",
+		"AUTH_ISSUER", "is required unless AUTH_JWKS_URL, AUTH_PUBLIC_KEY_FILE or AUTH_HS256_SECRET is set")
+	check(auth.Issuer == "" || isHTTPURL(auth.Issuer), "AUTH_ISSUER", "must be an http or https URL, got %q", auth.Issuer)
+	check(auth.JWKSURL == "" || isHTTPURL(auth.JWKSURL), "AUTH_JWKS_URL", "must be an http or https URL, got %q", auth.JWKSURL)
+	if auth.PublicKeyFile != "" {
+		_, err := os.Stat(auth.PublicKeyFile)
+		check(err == nil, "AUTH_PUBLIC_KEY_FILE", "%v", err)
+	}
+	check(auth.OrgClaim != "", "AUTH_ORG_CLAIM", "must not be empty")
+	check(auth.RoleClaim != "", "AUTH_ROLE_CLAIM", "must not be empty")
+	check(auth.DefaultRole == "admin" || auth.DefaultRole == "responder" || auth.DefaultRole == "viewer",
+		"AUTH_DEFAULT_ROLE", "must be admin, responder or viewer, got %q", auth.DefaultRole)
+
+	if smtp := cfg.SMTP; smtp.Enabled() {
+		check(smtp.Port > 0 && smtp.Port < 65536, "SMTP_PORT", "must be a port number, got %d", smtp.Port)
+		check(smtp.User != "" && smtp.Pass != "", "SMTP_USER", "SMTP_USER and SMTP_PASS are required with SMTP_HOST")
+		_, err := mail.ParseAddress(smtp.Sender)
+		check(err == nil, "SMTP_SENDER", "must be an email address, got %q", smtp.Sender)
+	}
+	for _, addr := range cfg.SMTP.NotifyTo {
+		_, err := mail.ParseAddress(addr)
+		check(err == nil, "SMTP_NOTIFY_TO", "%q is not an email address", addr)
+	}
+	if sms := cfg.SMS; sms.Enabled() {
+		check(sms.AuthToken != "" && sms.From != "", "SMS_ACCOUNT_SID", "SMS_AUTH_TOKEN and SMS_FROM are required with SMS_ACCOUNT_SID")
+		check(isHTTPURL(sms.APIURL), "SMS_API_URL", "must be an http or https URL, got %q", sms.APIURL)
+	}
+
+	sched := cfg.Scheduler
+	check(sched.DigestInterval > 0, "DIGEST_INTERVAL", "must be positive")
+	check(sched.EventLogPurgeInterval > 0, "EVENT_LOG_PURGE_INTERVAL", "must be positive")
+	check(sched.ArchivePurgeInterval > 0, "ARCHIVE_PURGE_INTERVAL", "must be positive")
+	check(sched.ArchiveRetentionDays > 0, "ARCHIVE_RETENTION_DAYS", "must be a positive number of days")
+	return errs
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && isHTTPURL(s) && u.Path == "" && u.RawQuery == ""
+}
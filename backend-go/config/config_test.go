@@ -0,0 +1,77 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() Config {
+	cfg := Default()
+	cfg.Database.URL = "postgres://localhost/clearstatus"
+	cfg.Auth.Issuer = "https://auth.example.com"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*Config)
+		// environment variables the errors must name, in order; none for a valid config
+		want []string
+	}{
+		{"defaults with database and issuer", func(*Config) {}, nil},
+		{"hs256 instead of issuer", func(c *Config) { c.Auth.Issuer = ""; c.Auth.HS256Secret = "dev" }, nil},
+		{"missing database", func(c *Config) { c.Database.URL = "" }, []string{"DATABASE_URL"}},
+		{"no key source", func(c *Config) { c.Auth.Issuer = "" }, []string{"AUTH_ISSUER"}},
+		{"issuer not a URL", func(c *Config) { c.Auth.Issuer = "auth.example.com" }, []string{"AUTH_ISSUER"}},
+		{"listen address without port", func(c *Config) { c.ListenAddr = "localhost" }, []string{"LISTEN_ADDR"}},
+		{"origin with path", func(c *Config) { c.CORSOrigins = []string{"https://example.com/app"} }, []string{"CORS_ORIGINS"}},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, []string{"LOG_LEVEL"}},
+		{"idle above open", func(c *Config) { c.Database.MaxOpenConns = 2; c.Database.MaxIdleConns = 5 }, []string{"DB_MAX_IDLE_CONNS"}},
+		{"unknown default role", func(c *Config) { c.Auth.DefaultRole = "member" }, []string{"AUTH_DEFAULT_ROLE"}},
+		{"smtp without credentials", func(c *Config) { c.SMTP.Host = "smtp.example.com"; c.SMTP.Sender = "status@example.com" }, []string{"SMTP_USER"}},
+		{"bad notify address", func(c *Config) { c.SMTP.NotifyTo = []string{"ops"} }, []string{"SMTP_NOTIFY_TO"}},
+		{"sms without sender", func(c *Config) { c.SMS.AccountSID = "AC123"; c.SMS.AuthToken = "secret" }, []string{"SMS_ACCOUNT_SID"}},
+		{"zero intervals", func(c *Config) {
+			c.ShutdownTimeout = 0
+			c.Scheduler.DigestInterval = 0
+			c.Scheduler.ArchiveRetentionDays = 0
+		}, []string{"SHUTDOWN_TIMEOUT", "DIGEST_INTERVAL", "ARCHIVE_RETENTION_DAYS"}},
+		{"negative lifetime", func(c *Config) { c.Database.ConnMaxLifetime = -time.Second }, []string{"DB_CONN_MAX_LIFETIME"}},
+		{"negative shutdown delay", func(c *Config) { c.ShutdownDelay = -time.Second }, []string{"SHUTDOWN_DELAY"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			tc.modify(&cfg)
+			errs := cfg.validate()
+			if len(errs) != len(tc.want) {
+				t.Fatalf("got %d errors %v, want %v", len(errs), errs, tc.want)
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err.Error(), tc.want[i]+":") {
+					t.Errorf("error %d = %q, want it to name %s", i, err, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadReportsInvalidEnvironment(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DATABASE_URL", "postgres://localhost/clearstatus")
+	t.Setenv("AUTH_HS256_SECRET", "dev")
+	t.Setenv("DB_MAX_OPEN_CONNS", "lots")
+	t.Setenv("DIGEST_INTERVAL", "5")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, name := range []string{"DB_MAX_OPEN_CONNS", "DIGEST_INTERVAL"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not name %s", err, name)
+		}
+	}
+}
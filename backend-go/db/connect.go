@@ -2,26 +2,32 @@ package db
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 	"os"
 
+	"backend-go/config"
+
 	_ "github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func ConnectDB() {
-	connStr := os.Getenv("DATABASE_URL")
+func ConnectDB(cfg config.Database) {
 	var err error
-	DB, err = sql.Open("postgres", connStr)
+	DB, err = sql.Open("postgres", cfg.URL)
 	if err != nil {
-		log.Fatal("❌ Failed to connect to DB:", err)
+		slog.Error("failed to connect to DB", "err", err)
+		os.Exit(1)
 	}
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	err = DB.Ping()
 	if err != nil {
-		log.Fatal("❌ DB not reachable:", err)
+		slog.Error("DB not reachable", "err", err)
+		os.Exit(1)
 	}
 
-	log.Println("✅ Connected to Railway PostgreSQL")
+	slog.Info("connected to PostgreSQL")
 }
@@ -0,0 +1,55 @@
+// Package dbtest connects tests to a scratch Postgres database. Tests that need
+// one are skipped unless TEST_DATABASE_URL names it.
+package dbtest
+
+import (
+	"backend-go/db"
+	"backend-go/migrations"
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	once    sync.Once
+	conn    *sql.DB
+	openErr error
+)
+
+// Open connects to the database named by TEST_DATABASE_URL, applying the schema
+// on first use, and points db.DB at it for the rest of the test binary so that
+// background work started by a test can outlive it. It skips t when the
+// variable is unset.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	once.Do(func() {
+		conn, openErr = sql.Open("postgres", url)
+		if openErr == nil {
+			openErr = migrate(conn)
+		}
+		db.DB = conn
+	})
+	if openErr != nil {
+		t.Fatal(openErr)
+	}
+	return conn
+}
+
+// migrate brings the database up to the latest migration. The migrator's lock
+// serializes test binaries sharing the database.
+func migrate(conn *sql.DB) error {
+	m, err := db.NewMigrator(conn, migrations.FS)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(context.Background(), 0)
+	return err
+}
@@ -0,0 +1,272 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationLockID is the pg_advisory_lock key held while migrating, so instances
+// starting together apply each migration once.
+const migrationLockID = 727361
+
+// Migration is one schema version; Down is empty when it cannot be reverted.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes a migration known to the files, the database or both.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	// the file changed after it was applied
+	Modified bool `json:"modified"`
+	// applied but no longer present in the files
+	Missing bool `json:"missing"`
+}
+
+// LoadMigrations reads NNN_name.sql and NNN_name.down.sql files from fsys, ordered by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".sql")
+		down := strings.HasSuffix(base, ".down")
+		base = strings.TrimSuffix(base, ".down")
+
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: name must look like 001_description.sql", file)
+		}
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %s", file, version, m.Name)
+		}
+		if down {
+			m.Down = string(body)
+		} else {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has a down file but no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts migrations, recording them in schema_migrations.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, applied map[int]appliedMigration) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	applied, err := loadApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+func loadApplied(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var (
+			version int
+			a       appliedMigration
+		)
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// Up applies pending migrations up to and including target, or all of them when
+// target is 0. It refuses to run if an applied migration's file has been edited.
+func (m *Migrator) Up(ctx context.Context, target int) (ran []Migration, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		for _, mig := range m.migrations {
+			if a, ok := applied[mig.Version]; ok && a.checksum != mig.Checksum {
+				return fmt.Errorf("migration %03d_%s was modified after it was applied", mig.Version, mig.Name)
+			}
+		}
+		for _, mig := range m.migrations {
+			if target > 0 && mig.Version > target {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := runInTx(ctx, conn, mig.Up, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				mig.Version, mig.Name, mig.Checksum)
+			if err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			slog.Info("migration applied", "version", mig.Version, "name", mig.Name)
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down reverts the latest steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) (ran []Migration, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down migration", mig.Version, mig.Name)
+			}
+			err := runInTx(ctx, conn, mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+			if err != nil {
+				return fmt.Errorf("revert %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			slog.Info("migration reverted", "version", mig.Version, "name", mig.Name)
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Status reports every migration in the files and the database, ordered by version.
+func (m *Migrator) Status(ctx context.Context) (status []MigrationStatus, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		for _, mig := range m.migrations {
+			s := MigrationStatus{Version: mig.Version, Name: mig.Name}
+			if a, ok := applied[mig.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = &a.appliedAt
+				s.Modified = a.checksum != mig.Checksum
+				delete(applied, mig.Version)
+			}
+			status = append(status, s)
+		}
+		for version, a := range applied {
+			appliedAt := a.appliedAt
+			status = append(status, MigrationStatus{Version: version, Name: a.name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+		}
+		sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+		return nil
+	})
+	return status, err
+}
+
+// runInTx executes script and the bookkeeping statement in one transaction.
+func runInTx(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// FormatStatus renders a status report as a table.
+func FormatStatus(status []MigrationStatus) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-8s %-40s %-10s %s\n", "VERSION", "NAME", "STATE", "APPLIED AT")
+	for _, s := range status {
+		state, appliedAt := "pending", ""
+		switch {
+		case s.Missing:
+			state = "missing"
+		case s.Modified:
+			state = "modified"
+		case s.Applied:
+			state = "applied"
+		}
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "%-8s %-40s %-10s %s\n", fmt.Sprintf("%03d", s.Version), s.Name, state, appliedAt)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"backend-go/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_status.sql":    {Data: []byte("ALTER TABLE t ADD status TEXT;")},
+		"001_create_t.sql":      {Data: []byte("CREATE TABLE t (id INT);")},
+		"001_create_t.down.sql": {Data: []byte("DROP TABLE t;")},
+		"010_backfill.sql":      {Data: []byte("UPDATE t SET status = 'ok';")},
+		"README.md":             {Data: []byte("not a migration")},
+	}
+	got, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d migrations, want 3", len(got))
+	}
+	for i, want := range []struct {
+		version int
+		name    string
+		down    bool
+	}{{1, "create_t", true}, {2, "add_status", false}, {10, "backfill", false}} {
+		m := got[i]
+		if m.Version != want.version || m.Name != want.name || (m.Down != "") != want.down {
+			t.Errorf("migration %d = %d %s (down %q)", i, m.Version, m.Name, m.Down)
+		}
+		if len(m.Checksum) != 64 {
+			t.Errorf("migration %d has checksum %q", i, m.Checksum)
+		}
+	}
+	if got[0].Up != "CREATE TABLE t (id INT);" {
+		t.Errorf("up = %q", got[0].Up)
+	}
+}
+
+func TestLoadMigrationsChecksumCoversUpOnly(t *testing.T) {
+	a, err := LoadMigrations(fstest.MapFS{"001_t.sql": {Data: []byte("CREATE TABLE t ();")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadMigrations(fstest.MapFS{
+		"001_t.sql":      {Data: []byte("CREATE TABLE t ();")},
+		"001_t.down.sql": {Data: []byte("DROP TABLE t;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadMigrations(fstest.MapFS{"001_t.sql": {Data: []byte("CREATE TABLE t (id INT);")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0].Checksum != b[0].Checksum {
+		t.Error("adding a down file must not change the checksum")
+	}
+	if a[0].Checksum == c[0].Checksum {
+		t.Error("changing the up file must change the checksum")
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"unnumbered":     {"create_t.sql": {Data: []byte("")}},
+		"no separator":   {"001.sql": {Data: []byte("")}},
+		"reused version": {"001_a.sql": {Data: []byte("")}, "001_b.sql": {Data: []byte("")}},
+		"down only":      {"001_a.down.sql": {Data: []byte("DROP TABLE a;")}},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(fsys); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	got, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range got {
+		if m.Version != i+1 {
+			t.Fatalf("migration %s has version %d, want %d; versions must be contiguous", m.Name, m.Version, i+1)
+		}
+		if strings.TrimSpace(m.Up) == "" {
+			t.Errorf("migration %03d_%s is empty", m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// ReplaySize is how many recent events are kept per organization for reconnecting clients.
+	ReplaySize       = 256
+	subscriberBuffer = 64
+)
+
+// Event is a typed change notification for one organization.
+type Event struct {
+	Seq            int64       `json:"seq"`
+	Type           string      `json:"event"`
+	ID             string      `json:"id"`
+	OrganizationID string      `json:"organizationId"`
+	Data           interface{} `json:"data"`
+	// IDs of other entities the event concerns, e.g. the services an incident affects
+	Related []string `json:"related,omitempty"`
+}
+
+// publicEvents may be shown to anonymous visitors of an org's status page.
+var publicEvents = map[string]bool{
+	"service_created":       true,
+	"service_updated":       true,
+	"service_archived":      true,
+	"service_restored":      true,
+	"incident_created":      true,
+	"incident_updated":      true,
+	"incident_update_added": true,
+	"incident_archived":     true,
+	"incident_restored":     true,
+}
+
+// Public reports whether the event is safe to send to a public status page.
+func (e Event) Public() bool {
+	return publicEvents[e.Type]
+}
+
+// Subscriber receives the events of a single organization. Dropped is closed if
+// the subscriber falls too far behind; it should then disconnect so the client
+// reconnects with Last-Event-ID and catches up from the replay buffer.
+type Subscriber struct {
+	C          chan Event
+	Dropped    chan struct{}
+	orgID      string
+	publicOnly bool
+	dropped    bool
+}
+
+// orgLog is the replay buffer of one organization.
+type orgLog struct {
+	events []Event
+	// highest Seq evicted from events; clients behind it cannot be caught up
+	evicted int64
+}
+
+// Hub fans events out to subscribers of the event's organization.
+type Hub struct {
+	subscribers map[string]map[*Subscriber]struct{}
+	logs        map[string]*orgLog
+	seq         int64
+	// first Seq issued by this process; earlier IDs come from a previous run
+	startSeq int64
+	lock     sync.Mutex
+	// closed by Close when the server shuts down
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewHub() *Hub {
+	// Seed IDs from the clock so they keep increasing across restarts.
+	start := time.Now().UnixMicro()
+	return &Hub{
+		subscribers: make(map[string]map[*Subscriber]struct{}),
+		logs:        make(map[string]*orgLog),
+		seq:         start,
+		startSeq:    start + 1,
+		done:        make(chan struct{}),
+	}
+}
+
+// Close tells every stream to disconnect, e.g. on shutdown. Clients reconnect,
+// possibly to another instance, and replay what they missed.
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// Done is closed once Close has been called.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Default is the process-wide hub used by the HTTP handlers.
+var Default = NewHub()
+
+// Publisher hands an event to every instance's hub.
+type Publisher interface {
+	Publish(e Event) error
+}
+
+// publisher is the in-process hub until ListenPostgres switches to NOTIFY fan-out.
+var publisher Publisher = Default
+
+// Publish sends e to the subscribers of its organization on every instance.
+func Publish(e Event) error {
+	return publisher.Publish(e)
+}
+
+// Subscribe registers a subscriber for orgID. Public subscribers only receive
+// events for which Event.Public is true.
+//
+// When lastSeq is non-zero the events after it are returned for replay. complete
+// is false when some of them are no longer buffered and the client must refetch.
+func (h *Hub) Subscribe(orgID string, publicOnly bool, lastSeq int64) (sub *Subscriber, replay []Event, complete bool) {
+	sub = &Subscriber{
+		C:          make(chan Event, subscriberBuffer),
+		Dropped:    make(chan struct{}),
+		orgID:      orgID,
+		publicOnly: publicOnly,
+	}
+	complete = true
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if lastSeq > 0 {
+		log := h.logs[orgID]
+		if lastSeq < h.startSeq-1 || (log != nil && lastSeq < log.evicted) {
+			complete = false
+		}
+		if log != nil {
+			for _, e := range log.events {
+				if e.Seq > lastSeq && (!publicOnly || e.Public()) {
+					replay = append(replay, e)
+				}
+			}
+		}
+	}
+
+	if h.subscribers[orgID] == nil {
+		h.subscribers[orgID] = make(map[*Subscriber]struct{})
+	}
+	h.subscribers[orgID][sub] = struct{}{}
+	return sub, replay, complete
+}
+
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.lock.Lock()
+	delete(h.subscribers[s.orgID], s)
+	if len(h.subscribers[s.orgID]) == 0 {
+		delete(h.subscribers, s.orgID)
+	}
+	h.lock.Unlock()
+}
+
+// Publish assigns e the next local sequence number and delivers it. It is used
+// when events are not relayed through Postgres.
+func (h *Hub) Publish(e Event) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	e.Seq = h.seq + 1
+	h.deliver(e)
+	return nil
+}
+
+// Resume continues numbering after lastSeq, the newest event already issued by
+// any instance; clients reconnecting with an older ID are told to resync.
+func (h *Hub) Resume(lastSeq int64) {
+	h.lock.Lock()
+	h.seq = lastSeq
+	h.startSeq = lastSeq + 1
+	h.lock.Unlock()
+}
+
+// Deliver records an already numbered event for replay and sends it to the
+// subscribers of its organization.
+func (h *Hub) Deliver(e Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.deliver(e)
+}
+
+func (h *Hub) deliver(e Event) {
+	if e.Seq > h.seq {
+		h.seq = e.Seq
+	}
+
+	log := h.logs[e.OrganizationID]
+	if log == nil {
+		log = &orgLog{}
+		h.logs[e.OrganizationID] = log
+	}
+	// relayed events can arrive twice after a listener reconnect
+	for i := len(log.events) - 1; i >= 0 && log.events[i].Seq >= e.Seq; i-- {
+		if log.events[i].Seq == e.Seq {
+			return
+		}
+	}
+	log.events = append(log.events, e)
+	if len(log.events) > ReplaySize {
+		log.evicted = log.events[0].Seq
+		log.events = log.events[1:]
+	}
+
+	for s := range h.subscribers[e.OrganizationID] {
+		if s.publicOnly && !e.Public() {
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+			if !s.dropped {
+				s.dropped = true
+				close(s.Dropped)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package events
+
+import "testing"
+
+// received drains the events already buffered for s.
+func received(s *Subscriber) []Event {
+	var got []Event
+	for {
+		select {
+		case e := <-s.C:
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+// subscribe subscribes without replay.
+func subscribe(h *Hub, orgID string, publicOnly bool) *Subscriber {
+	s, _, _ := h.Subscribe(orgID, publicOnly, 0)
+	return s
+}
+
+// publish publishes e and returns it as recorded, with its seq.
+func publish(h *Hub, e Event) Event {
+	h.Publish(e)
+	log := h.logs[e.OrganizationID].events
+	return log[len(log)-1]
+}
+
+func TestHubScopesEventsToOrganization(t *testing.T) {
+	h := NewHub()
+	a := subscribe(h, "org_a", false)
+	b := subscribe(h, "org_b", false)
+
+	h.Publish(Event{Type: "service_updated", ID: "1", OrganizationID: "org_a"})
+
+	if got := received(a); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("org_a received %v, want event 1", got)
+	}
+	if got := received(b); len(got) != 0 {
+		t.Errorf("org_b received %v, want nothing", got)
+	}
+}
+
+func TestHubPublicSubscribers(t *testing.T) {
+	h := NewHub()
+	public := subscribe(h, "org_a", true)
+	private := subscribe(h, "org_a", false)
+
+	h.Publish(Event{Type: "incident_created", ID: "1", OrganizationID: "org_a"})
+	h.Publish(Event{Type: "webhook_created", ID: "2", OrganizationID: "org_a"})
+
+	if got := received(public); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("public subscriber received %v, want only the incident", got)
+	}
+	if got := received(private); len(got) != 2 {
+		t.Errorf("private subscriber received %v, want both events", got)
+	}
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	h := NewHub()
+	s := subscribe(h, "org_a", false)
+	h.Unsubscribe(s)
+
+	h.Publish(Event{Type: "service_updated", ID: "1", OrganizationID: "org_a"})
+	if got := received(s); len(got) != 0 {
+		t.Errorf("unsubscribed subscriber received %v", got)
+	}
+	if len(h.subscribers) != 0 {
+		t.Errorf("hub still tracks %d organizations", len(h.subscribers))
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	h := NewHub()
+	slow := subscribe(h, "org_a", false)
+	for i := 0; i < cap(slow.C); i++ {
+		h.Publish(Event{Type: "service_updated", OrganizationID: "org_a"})
+	}
+	select {
+	case <-slow.Dropped:
+		t.Fatal("dropped a subscriber whose buffer still had room")
+	default:
+	}
+
+	// neither of these may block, and the second must not close Dropped again
+	h.Publish(Event{Type: "service_updated", OrganizationID: "org_a"})
+	h.Publish(Event{Type: "service_updated", OrganizationID: "org_a"})
+	select {
+	case <-slow.Dropped:
+	default:
+		t.Fatal("Dropped not closed after the subscriber missed an event")
+	}
+	if got := len(received(slow)); got != cap(slow.C) {
+		t.Errorf("slow subscriber received %d events, want its buffer of %d", got, cap(slow.C))
+	}
+}
+
+func TestHubAssignsIncreasingSeq(t *testing.T) {
+	h := NewHub()
+	a := publish(h, Event{Type: "service_updated", OrganizationID: "org_a"})
+	b := publish(h, Event{Type: "service_updated", OrganizationID: "org_b"})
+	if a.Seq <= 0 || b.Seq != a.Seq+1 {
+		t.Errorf("seqs %d, %d; want consecutive positive numbers", a.Seq, b.Seq)
+	}
+	if later := publish(NewHub(), Event{OrganizationID: "org_a"}); later.Seq <= b.Seq {
+		t.Errorf("a restarted hub issued %d after %d", later.Seq, b.Seq)
+	}
+}
+
+func TestHubReplaysFromLastEventID(t *testing.T) {
+	h := NewHub()
+	first := publish(h, Event{Type: "service_updated", ID: "1", OrganizationID: "org_a"})
+	h.Publish(Event{Type: "webhook_created", ID: "2", OrganizationID: "org_a"})
+	h.Publish(Event{Type: "service_updated", ID: "3", OrganizationID: "org_b"})
+	h.Publish(Event{Type: "incident_created", ID: "4", OrganizationID: "org_a"})
+
+	_, replay, complete := h.Subscribe("org_a", false, first.Seq)
+	if !complete || len(replay) != 2 || replay[0].ID != "2" || replay[1].ID != "4" {
+		t.Errorf("replay = %v, complete %v; want events 2 and 4", replay, complete)
+	}
+	_, replay, complete = h.Subscribe("org_a", true, first.Seq)
+	if !complete || len(replay) != 1 || replay[0].ID != "4" {
+		t.Errorf("public replay = %v, complete %v; want event 4", replay, complete)
+	}
+	if _, replay, _ = h.Subscribe("org_a", false, 0); len(replay) != 0 {
+		t.Errorf("replay without Last-Event-ID = %v", replay)
+	}
+}
+
+func TestHubReplayAfterEviction(t *testing.T) {
+	h := NewHub()
+	var seqs []int64
+	for i := 0; i < ReplaySize+2; i++ {
+		seqs = append(seqs, publish(h, Event{Type: "service_updated", OrganizationID: "org_a"}).Seq)
+	}
+
+	if _, _, complete := h.Subscribe("org_a", false, seqs[0]); complete {
+		t.Error("replay from an evicted event reported complete")
+	}
+	_, replay, complete := h.Subscribe("org_a", false, seqs[1])
+	if !complete || len(replay) != ReplaySize || replay[0].Seq != seqs[2] {
+		t.Errorf("replay from the last evicted event: %d events, complete %v", len(replay), complete)
+	}
+	if _, _, complete := h.Subscribe("org_a", false, seqs[0]-1000); complete {
+		t.Error("replay from before this process started reported complete")
+	}
+}
+
+func TestHubResume(t *testing.T) {
+	h := NewHub()
+	h.Resume(100)
+	if e := publish(h, Event{Type: "service_updated", OrganizationID: "org_a"}); e.Seq != 101 {
+		t.Errorf("first event after Resume(100) has seq %d", e.Seq)
+	}
+	if _, _, complete := h.Subscribe("org_a", false, 50); complete {
+		t.Error("replay from before the resumed seq reported complete")
+	}
+	_, replay, complete := h.Subscribe("org_a", false, 100)
+	if !complete || len(replay) != 1 || replay[0].Seq != 101 {
+		t.Errorf("replay from the resumed seq = %v, complete %v", replay, complete)
+	}
+}
+
+func TestHubDeliverSkipsDuplicates(t *testing.T) {
+	h := NewHub()
+	h.Resume(4)
+	s := subscribe(h, "org_a", false)
+	for _, seq := range []int64{5, 7, 5, 7} {
+		h.Deliver(Event{Seq: seq, Type: "service_updated", OrganizationID: "org_a"})
+	}
+	if got := received(s); len(got) != 2 || got[0].Seq != 5 || got[1].Seq != 7 {
+		t.Errorf("subscriber received %v, want seqs 5 and 7 once each", got)
+	}
+	if e := publish(h, Event{OrganizationID: "org_a"}); e.Seq != 8 {
+		t.Errorf("next local seq = %d, want 8", e.Seq)
+	}
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"backend-go/db"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	notifyChannel = "clearstatus_events"
+	// how long event_log rows are kept for instances catching up after a reconnect
+	eventLogRetention = time.Hour
+	// how often the relay looks again while waiting on a gap in event_log
+	gapRetryInterval = 500 * time.Millisecond
+)
+
+// how long a gap in event_log is waited on before the relay moves past it
+var gapTimeout = 5 * time.Second
+
+// pgBroker publishes events by writing them to event_log and notifying every
+// instance, each of which relays them to its local hub.
+//
+// Sequence numbers are taken when an insert starts but become visible when it
+// commits, so seq 6 can be notified before seq 5. The relay therefore reads
+// event_log from the last seq it delivered and stops at the first gap. A gap
+// that stays open for gapTimeout belongs to an insert that failed and is skipped.
+type pgBroker struct {
+	hub  *Hub
+	lock sync.Mutex
+	// every seq up to delivered was delivered to the hub or skipped
+	delivered int64
+	// when the relay first found the seq after delivered missing; zero when not waiting
+	gapSince time.Time
+}
+
+// ListenPostgres switches Publish to LISTEN/NOTIFY fan-out so every backend
+// instance sharing the database sees every event.
+func ListenPostgres(connStr string) error {
+	b := &pgBroker{hub: Default}
+
+	// Resume from the sequence rather than MAX(seq): PurgeLog may have emptied
+	// event_log, and IDs clients hold must keep comparing as older than new events.
+	var last int64
+	if err := db.DB.QueryRow(`SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM event_log_seq_seq`).Scan(&last); err != nil {
+		return err
+	}
+	b.delivered = last
+	b.hub.Resume(last)
+
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("event listener", "err", err)
+		}
+	})
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return err
+	}
+
+	publisher = b
+	go b.relay(listener)
+	return nil
+}
+
+func (b *pgBroker) Publish(e Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	related := e.Related
+	if related == nil {
+		related = []string{}
+	}
+	_, err = db.DB.Exec(`WITH e AS (
+			INSERT INTO event_log (organization_id, type, entity_id, data, related) VALUES ($1, $2, $3, $4, $5) RETURNING seq
+		) SELECT pg_notify($6, seq::text) FROM e`,
+		e.OrganizationID, e.Type, e.ID, data, pq.Array(related), notifyChannel)
+	return err
+}
+
+func (b *pgBroker) relay(listener *pq.Listener) {
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		var retry <-chan time.Time
+		if b.waiting() {
+			retry = time.After(gapRetryInterval)
+		}
+		// A notification only says that something new was committed; a nil one means
+		// the connection was re-established and notifications may have been missed.
+		// Either way the relay reads everything after the last delivered seq.
+		select {
+		case <-listener.Notify:
+			b.catchUp()
+		case <-retry:
+			b.catchUp()
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
+
+// waiting reports whether the relay is waiting on a gap in event_log.
+func (b *pgBroker) waiting() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return !b.gapSince.IsZero()
+}
+
+// catchUp delivers the events after the last delivered one in seq order, up to
+// the first gap that has not timed out yet.
+func (b *pgBroker) catchUp() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	rows, err := db.DB.Query(`SELECT seq, organization_id, type, entity_id, data, related FROM event_log WHERE seq > $1 ORDER BY seq`, b.delivered)
+	if err != nil {
+		slog.Error("could not load events", "err", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e    Event
+			data sql.RawBytes
+		)
+		if err := rows.Scan(&e.Seq, &e.OrganizationID, &e.Type, &e.ID, &data, pq.Array(&e.Related)); err != nil {
+			slog.Error("could not read event", "err", err)
+			return
+		}
+		if e.Seq != b.delivered+1 {
+			if b.gapSince.IsZero() {
+				b.gapSince = time.Now()
+			}
+			if time.Since(b.gapSince) < gapTimeout {
+				return
+			}
+			slog.Warn("skipping events that never committed", "from", b.delivered+1, "to", e.Seq-1)
+		}
+		if len(data) > 0 {
+			e.Data = json.RawMessage(append([]byte(nil), data...))
+		}
+		b.delivered = e.Seq
+		b.gapSince = time.Time{}
+		b.hub.Deliver(e)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("could not load events", "err", err)
+	}
+}
+
+// PurgeLog deletes relayed events that no instance needs for catching up anymore.
+func PurgeLog() error {
+	_, err := db.DB.Exec(`DELETE FROM event_log WHERE created_at < $1`, time.Now().Add(-eventLogRetention))
+	return err
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"backend-go/db/dbtest"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTestBroker returns a broker that has delivered every event issued so far.
+func newTestBroker(t *testing.T, conn *sql.DB) *pgBroker {
+	t.Helper()
+	var last int64
+	if err := conn.QueryRow(`SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM event_log_seq_seq`).Scan(&last); err != nil {
+		t.Fatal(err)
+	}
+	b := &pgBroker{hub: NewHub(), delivered: last}
+	b.hub.Resume(last)
+	return b
+}
+
+// eventIDs returns the IDs of events, checking that their seqs increase.
+func eventIDs(t *testing.T, events []Event) []string {
+	t.Helper()
+	var ids []string
+	for i, e := range events {
+		if i > 0 && e.Seq <= events[i-1].Seq {
+			t.Errorf("event %s (seq %d) delivered after seq %d", e.ID, e.Seq, events[i-1].Seq)
+		}
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestCatchUpDeliversMissedEventsInOrder(t *testing.T) {
+	conn := dbtest.Open(t)
+	b := newTestBroker(t, conn)
+	orgID := "org_" + uuid.NewString()
+	sub := subscribe(b.hub, orgID, false)
+
+	for _, id := range []string{"1", "2", "3"} {
+		if err := b.Publish(Event{Type: "service_updated", ID: id, OrganizationID: orgID, Data: map[string]string{"id": id}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.catchUp()
+
+	got := received(sub)
+	if len(got) != 3 {
+		t.Fatalf("received %d events, want 3", len(got))
+	}
+	for i, e := range got {
+		if e.ID != []string{"1", "2", "3"}[i] || (i > 0 && e.Seq <= got[i-1].Seq) {
+			t.Errorf("event %d = %+v, out of order", i, e)
+		}
+		var data map[string]string
+		if raw, ok := e.Data.(json.RawMessage); !ok || json.Unmarshal(raw, &data) != nil || data["id"] != e.ID {
+			t.Errorf("event %d data = %v", i, e.Data)
+		}
+	}
+	if b.delivered != got[2].Seq {
+		t.Errorf("delivered = %d, want %d", b.delivered, got[2].Seq)
+	}
+
+	b.catchUp()
+	if again := received(sub); len(again) != 0 {
+		t.Errorf("second catch-up redelivered %v", again)
+	}
+}
+
+func TestCatchUpWaitsForUncommittedEvents(t *testing.T) {
+	conn := dbtest.Open(t)
+	b := newTestBroker(t, conn)
+	orgID := "org_" + uuid.NewString()
+	sub := subscribe(b.hub, orgID, false)
+
+	// the first event takes its seq but commits after the second
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO event_log (organization_id, type, entity_id) VALUES ($1, 'service_updated', '1')`, orgID); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(Event{Type: "service_updated", ID: "2", OrganizationID: orgID}); err != nil {
+		t.Fatal(err)
+	}
+
+	b.catchUp()
+	if got := received(sub); len(got) != 0 {
+		t.Fatalf("delivered %v ahead of an uncommitted event", eventIDs(t, got))
+	}
+	if !b.waiting() {
+		t.Fatal("relay is not waiting on the gap")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	b.catchUp()
+	if got := eventIDs(t, received(sub)); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Fatalf("delivered %v, want [1 2]", got)
+	}
+	if b.waiting() {
+		t.Error("relay still waiting after the gap closed")
+	}
+}
+
+func TestCatchUpSkipsAbandonedGap(t *testing.T) {
+	conn := dbtest.Open(t)
+	b := newTestBroker(t, conn)
+	orgID := "org_" + uuid.NewString()
+	sub := subscribe(b.hub, orgID, false)
+
+	// a failed insert uses up a seq that never appears in event_log
+	if _, err := conn.Exec(`SELECT nextval('event_log_seq_seq')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(Event{Type: "service_updated", ID: "1", OrganizationID: orgID}); err != nil {
+		t.Fatal(err)
+	}
+
+	b.catchUp()
+	if got := received(sub); len(got) != 0 {
+		t.Fatalf("skipped the gap at once and delivered %v", eventIDs(t, got))
+	}
+	b.gapSince = time.Now().Add(-gapTimeout)
+	b.catchUp()
+	if got := eventIDs(t, received(sub)); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Fatalf("delivered %v after the gap timed out, want [1]", got)
+	}
+	if b.waiting() {
+		t.Error("relay still waiting after skipping the gap")
+	}
+}
+
+func TestListenPostgresRelaysEvents(t *testing.T) {
+	conn := dbtest.Open(t)
+	prev := publisher
+	t.Cleanup(func() { publisher = prev })
+	if err := ListenPostgres(os.Getenv("TEST_DATABASE_URL")); err != nil {
+		t.Fatal(err)
+	}
+
+	var last int64
+	conn.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM event_log`).Scan(&last)
+	orgID := "org_" + uuid.NewString()
+	sub, _, _ := Default.Subscribe(orgID, false, 0)
+	defer Default.Unsubscribe(sub)
+
+	if err := Publish(Event{Type: "incident_created", ID: "1", OrganizationID: orgID}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-sub.C:
+		if e.ID != "1" || e.Seq <= last {
+			t.Errorf("relayed %+v, want event 1 with a seq after %d", e, last)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not relayed through NOTIFY")
+	}
+}
@@ -0,0 +1,119 @@
+// Package logging configures structured JSON logging with secret redaction and
+// request correlation.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Level is the minimum level logged; it can be changed while running.
+var Level = new(slog.LevelVar)
+
+type ctxKey struct{}
+
+// WithRequestID returns a context whose log records carry request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID returns the request ID stored by WithRequestID, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Setup installs the JSON logger as the slog and standard library default.
+// It logs at info until SetLevel applies the configured level.
+func Setup() {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       Level,
+		ReplaceAttr: redactAttr,
+	})
+	// also routes the standard log package, e.g. from dependencies, at info level
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// SetLevel parses and applies a level name; an empty name means info.
+func SetLevel(name string) error {
+	if name == "" {
+		name = "info"
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("unknown log level %q", name)
+	}
+	Level.Set(l)
+	return nil
+}
+
+// contextHandler adds request_id from the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveKey matches attribute names whose values are never logged.
+var sensitiveKey = regexp.MustCompile(`(?i)^(.*authorization|.*cookie|.*token|.*secret|.*password|api[_-]?key|x-api-key|.*signature)$`)
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[^\s"']+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]*`)
+	apiKeyPattern = regexp.MustCompile(`cs_[0-9a-f]{8}_[0-9a-f]+`)
+	// ?token= is how EventSource and inbound webhook callers authenticate
+	tokenParamPattern = regexp.MustCompile(`(?i)([?&](?:access_)?token=)[^&\s"']+`)
+	emailPattern      = regexp.MustCompile(`([\w.+-])[\w.+-]*@([\w-]+\.)+[\w-]+`)
+)
+
+// Redact masks tokens, API keys, token query parameters and email addresses in s.
+func Redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = apiKeyPattern.ReplaceAllString(s, redacted)
+	s = tokenParamPattern.ReplaceAllString(s, "${1}"+redacted)
+	return emailPattern.ReplaceAllString(s, "$1***@***")
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.TimeKey && a.Key != slog.LevelKey && sensitiveKey.MatchString(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(v.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, Redact(v.String()))
+		case []string:
+			out := make([]string, len(v))
+			for i, s := range v {
+				out[i] = Redact(s)
+			}
+			return slog.String(a.Key, strings.Join(out, ","))
+		}
+	}
+	return a
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	cases := []struct{ name, in, want string }{
+		{"bearer token", "Authorization: Bearer abc.def-123", "Authorization: Bearer [REDACTED]"},
+		{"lowercase bearer", `header "bearer xyz"`, `header "Bearer [REDACTED]"`},
+		{"bare JWT", "token eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOiJ1In0.c2ln expired", "token [REDACTED] expired"},
+		{"API key", "key cs_0a1b2c3d_9f8e7d6c5b4a rejected", "key [REDACTED] rejected"},
+		{"API key as bearer", "Bearer cs_0a1b2c3d_9f8e7d6c5b4a", "Bearer [REDACTED]"},
+		{"token query param", "GET /api/stream?token=abc123&types=incident", "GET /api/stream?token=[REDACTED]&types=incident"},
+		{"later token query param", "/api/hooks/inbound/1?x=1&token=ih_secret", "/api/hooks/inbound/1?x=1&token=[REDACTED]"},
+		{"access_token query param", "/cb?access_token=xyz", "/cb?access_token=[REDACTED]"},
+		{"email", "sent to jane.doe+ops@example.co.uk", "sent to j***@***"},
+		{"not a key", "cs_short and csv_files", "cs_short and csv_files"},
+		{"token word alone", "the token expired", "the token expired"},
+	}
+	for _, tc := range cases {
+		if got := Redact(tc.in); got != tc.want {
+			t.Errorf("%s: Redact(%q) = %q, want %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestRedactAttr(t *testing.T) {
+	u, _ := url.Parse("https://example.com/hook?token=secret")
+	cases := []struct {
+		attr slog.Attr
+		want string
+	}{
+		{slog.String("Authorization", "Bearer abc"), redacted},
+		{slog.String("X-Api-Key", "anything"), redacted},
+		{slog.String("api_key", "anything"), redacted},
+		{slog.String("webhook_secret", "whsec_1"), redacted},
+		{slog.String("resetToken", "t1"), redacted},
+		{slog.String("Cookie", "session=1"), redacted},
+		{slog.String("X-ClearStatus-Signature", "sha256=00"), redacted},
+		{slog.String("path", "/api/stream?token=abc"), "/api/stream?token=" + redacted},
+		{slog.Any("err", errors.New("dial with Bearer abc failed")), "dial with Bearer " + redacted + " failed"},
+		{slog.Any("url", u), "https://example.com/hook?token=" + redacted},
+		{slog.Any("to", []string{"a@example.com", "cs_0a1b2c3d_ff00"}), "a***@***," + redacted},
+		{slog.String("service", "API"), "API"},
+	}
+	for _, tc := range cases {
+		if got := redactAttr(nil, tc.attr).Value.String(); got != tc.want {
+			t.Errorf("redactAttr(%s) = %q, want %q", tc.attr.Key, got, tc.want)
+		}
+	}
+	if got := redactAttr(nil, slog.Int("count", 3)); got.Value.Int64() != 3 {
+		t.Errorf("non-string attr changed to %v", got)
+	}
+}
+
+func TestHandlerAddsRequestIDAndRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttr})})
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "request", "authorization", "Bearer abc", "path", "/x?token=t")
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["request_id"] != "req-1" || rec["authorization"] != redacted || rec["path"] != "/x?token="+redacted {
+		t.Errorf("logged %v", rec)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{"": slog.LevelInfo, "debug": slog.LevelDebug, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		if err := SetLevel(name); err != nil || Level.Level() != want {
+			t.Errorf("SetLevel(%q) = %v, level %v; want %v", name, err, Level.Level(), want)
+		}
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("SetLevel accepted an unknown level")
+	}
+}
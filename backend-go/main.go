@@ -2,32 +2,95 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"backend-go/config"
 	"backend-go/db"
+	"backend-go/events"
+	"backend-go/logging"
 	"backend-go/routes"
 	"backend-go/middleware"
+	"backend-go/notify"
+	"backend-go/scheduler"
+	"backend-go/store"
+	"backend-go/utils"
 
 )
 
 func main() {
 	// Try to load .env for local dev, but don't crash if missing (Railway uses env vars)
-	if err := godotenv.Load(); err != nil {
-		log.Println("⚠️  No .env file found (this is normal in production)")
+	err := godotenv.Load()
+	logging.Setup()
+	if err != nil {
+		slog.Info("no .env file found (this is normal in production)")
 	}
 
-	db.ConnectDB()
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+	logging.SetLevel(cfg.LogLevel)
+	middleware.UseAuth(cfg.Auth)
+	utils.UseSMTP(cfg.SMTP)
+	notify.UseNotifyTo(cfg.SMTP.NotifyTo)
+	notify.UseSMSProvider(utils.NewSMSProvider(cfg.SMS))
+
+	db.ConnectDB(cfg.Database)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if err := migrateOnStart(cfg); err != nil {
+		slog.Error("migrations failed", "err", err)
+		os.Exit(1)
+	}
+	routes.UseStores(store.NewPostgres(db.DB))
 
-	r := gin.Default()
+	// Relay real-time events between instances through Postgres LISTEN/NOTIFY
+	if err := events.ListenPostgres(cfg.Database.URL); err != nil {
+		slog.Warn("event listener unavailable, events stay on this instance", "err", err)
+	}
+
+	sched := scheduler.New()
+	sched.Every("email-digests", cfg.Scheduler.DigestInterval, notify.FlushDigests)
+	sched.Every("event-log-purge", cfg.Scheduler.EventLogPurgeInterval, events.PurgeLog)
+	sched.Every("archive-purge", cfg.Scheduler.ArchivePurgeInterval, func() error {
+		return routes.PurgeArchived(cfg.Scheduler.ArchiveRetention())
+	})
+
+	var draining atomic.Bool
+	r := gin.New()
+	// Probes for load balancers and orchestrators, registered before the request logger
+	// so they do not flood the logs
+	routes.RegisterHealthRoutes(r, map[string]routes.HealthCheck{
+		"database":  db.DB.PingContext,
+		"scheduler": func(context.Context) error { return sched.Check() },
+		"server": func(context.Context) error {
+			if draining.Load() {
+				return errors.New("shutting down")
+			}
+			return nil
+		},
+	})
+	r.Use(middleware.RequestID(), middleware.RequestLogger(), gin.Recovery())
 	r.Use(cors.New(cors.Config{
-	AllowOrigins:     []string{"http://localhost:3000", "https://clearstatus.vercel.app"},
-	AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-	AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-	ExposeHeaders:    []string{"Content-Length"},
+	AllowOrigins:     cfg.CORSOrigins,
+	AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+	AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Request-ID", "If-Match", "If-None-Match"},
+	ExposeHeaders:    []string{"Content-Length", "X-Request-ID", "ETag"},
 	AllowCredentials: true,
 }))
 
@@ -40,10 +103,29 @@ func main() {
 
 		routes.RegisterServiceRoutes(api)
 		routes.RegisterIncidentRoutes(api)
+		routes.RegisterWebhookRoutes(api)
+		routes.RegisterSMSRoutes(api)
+		routes.RegisterSubscriberRoutes(api)
+		routes.RegisterAlertmanagerRoutes(api)
+		routes.RegisterInboundRoutes(api)
+		routes.RegisterEscalationRoutes(api)
+		routes.RegisterStatusPageRoutes(api)
+		routes.RegisterAPIKeyRoutes(api)
+		routes.RegisterRoleRoutes(api)
+		routes.RegisterAuditRoutes(api)
 	}
 
-	// Register SSE route outside the auth group:
-	routes.RegisterStreamRoutes(r.Group("/api"))
+	// SSE and WebSocket: dashboard streams are org-scoped and authenticated (browsers send ?token=),
+	// status pages subscribe by public slug
+	stream := r.Group("/api")
+	stream.Use(middleware.QueryTokenAuth(), middleware.AuthMiddleware())
+	routes.RegisterStreamRoutes(stream)
+	routes.RegisterWebSocketRoutes(stream)
+	routes.RegisterPublicStreamRoutes(r.Group("/api"))
+
+	// Inbound integrations authenticate with their own tokens
+	routes.RegisterAlertmanagerHooks(r.Group("/api"))
+	routes.RegisterInboundHooks(r.Group("/api"))
 
 	// Register public GET endpoints for status page
 	r.GET("/api/public/services", routes.PublicGetServices)
@@ -51,5 +133,50 @@ func main() {
 
 	r.GET("/api/services/:id/uptime", routes.GetServiceUptime)
 
-	r.Run(":8080")
+	// Operator endpoints, e.g. changing the log level at runtime
+	routes.RegisterAdminRoutes(r.Group("/api/admin", middleware.AdminTokenAuth(cfg.AdminToken)))
+
+	// SIGTERM (deploys) and Ctrl-C stop the scheduler and start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	sched.Start(ctx)
+
+	srv := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// SSE and WebSocket streams never finish on their own; end them so Shutdown can complete
+	srv.RegisterOnShutdown(events.Default.Close)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "err", err)
+			os.Exit(1)
+		}
+	}()
+	slog.Info("listening", "addr", cfg.ListenAddr)
+
+	<-ctx.Done()
+	stop()
+	slog.Info("shutting down", "delay", cfg.ShutdownDelay, "timeout", cfg.ShutdownTimeout)
+	draining.Store(true)
+
+	// keep serving while /readyz fails so load balancers take the instance out of rotation
+	// before it stops accepting connections
+	time.Sleep(cfg.ShutdownDelay)
+
+	// the timeout covers the in-flight requests, scheduled jobs and background deliveries together
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP server did not shut down cleanly", "err", err)
+	}
+	if err := sched.Wait(shutdownCtx); err != nil {
+		slog.Error("scheduled jobs still running", "err", err)
+	}
+	if err := notify.Drain(shutdownCtx); err != nil {
+		slog.Error("notifications still being delivered", "err", err)
+	}
+	db.DB.Close()
+	slog.Info("shut down")
 }
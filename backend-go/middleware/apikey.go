@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"backend-go/db"
+	"backend-go/utils"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+const (
+	ScopeRead           = "read"
+	ScopeServicesWrite  = "services:write"
+	ScopeIncidentsWrite = "incidents:write"
+	ScopeAdmin          = "admin"
+
+	// APIKeyPrefix starts every API key, which lets AuthMiddleware tell keys from JWTs.
+	APIKeyPrefix = "cs_"
+)
+
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeRead, ScopeServicesWrite, ScopeIncidentsWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// NewAPIKey returns a key of the form cs_<prefix>_<secret> along with its prefix.
+// Only the hash of the full key is stored.
+func NewAPIKey() (key, prefix string, err error) {
+	prefix, err = utils.RandomToken("", 4)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := utils.RandomToken("", 24)
+	if err != nil {
+		return "", "", err
+	}
+	return APIKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// apiKeyFromRequest returns the API key presented as X-API-Key or as a cs_ bearer token.
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return token
+	}
+	return ""
+}
+
+// parseAPIKey returns the lookup prefix of a key shaped like NewAPIKey's output.
+func parseAPIKey(key string) (prefix string, ok bool) {
+	rest, found := strings.CutPrefix(key, APIKeyPrefix)
+	if !found {
+		return "", false
+	}
+	prefix, secret, found := strings.Cut(rest, "_")
+	if !found || len(prefix) != 8 || !isHex(prefix) || secret == "" || !isHex(secret) {
+		return "", false
+	}
+	return prefix, true
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// authenticateAPIKey validates key and stores its org, ID and scopes on the context.
+func authenticateAPIKey(c *gin.Context, key string) bool {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return false
+	}
+
+	var (
+		id, orgID, hash string
+		scopes          []string
+		expiresAt       sql.NullTime
+	)
+	err := db.DB.QueryRow(`SELECT id, organization_id, key_hash, scopes, expires_at FROM api_keys WHERE prefix = $1 AND revoked_at IS NULL`, prefix).
+		Scan(&id, &orgID, &hash, pq.Array(&scopes), &expiresAt)
+	if err != nil {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(key)), []byte(hash)) != 1 {
+		return false
+	}
+	if expiresAt.Valid && time.Now().After(expiresAt.Time) {
+		return false
+	}
+
+	go db.DB.Exec(`UPDATE api_keys SET last_used_at = now() WHERE id = $1`, id)
+
+	c.Set("organizationId", orgID)
+	c.Set("apiKeyId", id)
+	c.Set("apiKeyScopes", scopes)
+	return true
+}
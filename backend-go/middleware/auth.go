@@ -1,29 +1,26 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/MicahParks/keyfunc"
 )
 
-var jwks *keyfunc.JWKS
-
-func init() {
-	jwksURL := "https://rapid-mammal-51.clerk.accounts.dev/.well-known/jwks.json"
-	var err error
-	jwks, err = keyfunc.Get(jwksURL, keyfunc.Options{RefreshInterval: time.Hour})
-	if err != nil {
-		panic("❌ Failed to load JWKS from Clerk: " + err.Error())
-	}
-}
-
+// AuthMiddleware accepts either an organization API key (X-API-Key or a cs_ bearer
+// token) or a JWT from the configured identity provider carrying the org claim.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := apiKeyFromRequest(c); key != "" {
+			if !authenticateAPIKey(c, key) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				return
+			}
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
@@ -31,36 +28,38 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenStr, jwks.Keyfunc)
-		if err != nil || !token.Valid {
+		v := jwtVerifier()
+		claims, err := v.verify(tokenStr)
+		if err != nil {
+			slog.DebugContext(c.Request.Context(), "JWT rejected", "err", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims"})
+		orgID := stringClaim(claims, v.orgClaim)
+		if orgID == "" {
+			slog.WarnContext(c.Request.Context(), "JWT missing org claim", "claim", v.orgClaim)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing " + v.orgClaim + " in token"})
 			return
 		}
-
-		orgID, ok := claims["org_id"].(string)
-		if !ok || orgID == "" {
-			log.Println("🔴 JWT missing org_id")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing org_id in token"})
-			return
+		c.Set("organizationId", orgID)
+		userID := stringClaim(claims, "sub")
+		if userID != "" {
+			c.Set("userId", userID)
 		}
-		// In auth.go, update the logging
-log.Printf("🔵 Incoming request path: %s", c.Request.URL.Path)
-log.Printf("🟢 Authenticating request for orgID: %s", orgID)
-log.Printf("🟣 Full claims: %+v", claims)
-log.Printf("🟠 Token: %s", tokenStr) // Be careful with this in production
+		c.Set("role", resolveRole(orgID, userID, stringClaim(claims, v.roleClaim)))
+		c.Next()
+	}
+}
 
-		// In auth.go, after getting orgID
-log.Printf("Authenticating request for orgID: %s", orgID)
-log.Printf("Full claims: %+v", claims)
-		c.Set("organizationId", orgID)
-		for k, v := range c.Request.Header {
-			log.Println("HEADER:", k, v)
+// QueryTokenAuth lets clients that cannot set headers, such as browser EventSource,
+// pass their bearer token as ?token=. It must run before AuthMiddleware.
+func QueryTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
 		}
 		c.Next()
 	}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs one line per request with its route, status, latency and
+// the organization and actor it was made for. It must run after RequestID.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if org := c.GetString("organizationId"); org != "" {
+			attrs = append(attrs, slog.String("org_id", org))
+		}
+		if user := c.GetString("userId"); user != "" {
+			attrs = append(attrs, slog.String("user_id", user))
+		}
+		if key := c.GetString("apiKeyId"); key != "" {
+			attrs = append(attrs, slog.String("api_key_id", key))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
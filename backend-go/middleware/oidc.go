@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"backend-go/config"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// jwksRetryInterval limits how often a failed JWKS fetch is retried.
+	jwksRetryInterval = 10 * time.Second
+	// jwksFetchTimeout bounds each JWKS request, and so how long requests wait on one.
+	jwksFetchTimeout = 5 * time.Second
+)
+
+var errAuthNotConfigured = errors.New("no AUTH_JWKS_URL, AUTH_ISSUER, AUTH_HS256_SECRET or AUTH_PUBLIC_KEY_FILE configured")
+
+// verifier validates bearer JWTs. Keys come from one of, in order of precedence:
+//
+//	AUTH_HS256_SECRET     shared secret, for tests and local development
+//	AUTH_PUBLIC_KEY_FILE  static RSA public key (PEM)
+//	AUTH_JWKS_URL         JWKS endpoint, defaulting to <AUTH_ISSUER>/.well-known/jwks.json
+//
+// AUTH_ISSUER and AUTH_AUDIENCE, when set, must match the iss and aud claims.
+// AUTH_ORG_CLAIM names the claim holding the organization ID (default org_id)
+// and AUTH_ROLE_CLAIM the one holding the org role (default org_role).
+type verifier struct {
+	issuer    string
+	audience  string
+	orgClaim  string
+	roleClaim string
+	jwksURL   string
+	hmacKey   []byte
+	rsaKey    *rsa.PublicKey
+	methods   []string
+
+	jwks    atomic.Pointer[keyfunc.JWKS]
+	initErr error
+
+	// lock guards lastFetch and fetching; it is never held during a fetch.
+	lock      sync.Mutex
+	lastFetch time.Time
+	fetching  chan struct{} // closed when the fetch in progress finishes
+}
+
+func newVerifier(cfg config.Auth) *verifier {
+	v := &verifier{
+		issuer:    strings.TrimSuffix(cfg.Issuer, "/"),
+		audience:  cfg.Audience,
+		orgClaim:  cfg.OrgClaim,
+		roleClaim: cfg.RoleClaim,
+		jwksURL:   cfg.JWKSURL,
+	}
+	switch {
+	case cfg.HS256Secret != "":
+		v.hmacKey = []byte(cfg.HS256Secret)
+		v.methods = []string{"HS256"}
+		slog.Warn("JWT auth uses AUTH_HS256_SECRET; do not use this mode in production")
+	case cfg.PublicKeyFile != "":
+		pem, err := os.ReadFile(cfg.PublicKeyFile)
+		if err == nil {
+			v.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		}
+		if err != nil {
+			v.initErr = fmt.Errorf("load AUTH_PUBLIC_KEY_FILE: %w", err)
+		}
+		v.methods = []string{"RS256", "RS384", "RS512"}
+	default:
+		if v.jwksURL == "" && v.issuer != "" {
+			v.jwksURL = v.issuer + "/.well-known/jwks.json"
+		}
+		if v.jwksURL == "" {
+			v.initErr = errAuthNotConfigured
+		}
+		v.methods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+	}
+	if v.initErr != nil {
+		slog.Error("JWT auth not configured", "err", v.initErr)
+	}
+	return v
+}
+
+// keyfunc returns the key for token, fetching the JWKS on first use.
+func (v *verifier) keyfunc(token *jwt.Token) (interface{}, error) {
+	if v.initErr != nil {
+		return nil, v.initErr
+	}
+	if v.hmacKey != nil {
+		return v.hmacKey, nil
+	}
+	if v.rsaKey != nil {
+		return v.rsaKey, nil
+	}
+
+	jwks := v.loadJWKS()
+	if jwks == nil {
+		return nil, errors.New("JWKS not available")
+	}
+	return jwks.Keyfunc(token)
+}
+
+// loadJWKS returns the JWKS, or nil while none could be fetched. Requests that
+// arrive during a fetch wait for that one fetch rather than starting their own;
+// a failed fetch is retried on a later request, at most once per jwksRetryInterval.
+func (v *verifier) loadJWKS() *keyfunc.JWKS {
+	if jwks := v.jwks.Load(); jwks != nil {
+		return jwks
+	}
+
+	v.lock.Lock()
+	done := v.fetching
+	if done == nil && time.Since(v.lastFetch) >= jwksRetryInterval {
+		done = make(chan struct{})
+		v.fetching = done
+		v.lastFetch = time.Now()
+		go v.fetchJWKS(done)
+	}
+	v.lock.Unlock()
+
+	if done != nil {
+		<-done
+	}
+	return v.jwks.Load()
+}
+
+func (v *verifier) fetchJWKS(done chan struct{}) {
+	jwks, err := keyfunc.Get(v.jwksURL, keyfunc.Options{
+		RefreshInterval:   time.Hour,
+		RefreshTimeout:    jwksFetchTimeout,
+		RefreshUnknownKID: true,
+		RefreshRateLimit:  time.Minute,
+		RefreshErrorHandler: func(err error) {
+			slog.Error("JWKS refresh failed", "err", err)
+		},
+	})
+	if err != nil {
+		slog.Error("failed to load JWKS", "url", v.jwksURL, "err", err)
+	} else {
+		v.jwks.Store(jwks)
+	}
+
+	v.lock.Lock()
+	v.fetching = nil
+	v.lock.Unlock()
+	close(done)
+}
+
+// verify parses tokenStr and checks its signature, exp, nbf, iss and aud.
+func (v *verifier) verify(tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(v.methods))
+	if _, err := parser.ParseWithClaims(tokenStr, claims, v.keyfunc); err != nil {
+		return nil, err
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("token has no exp or is expired")
+	}
+	if v.issuer != "" && strings.TrimSuffix(stringClaim(claims, "iss"), "/") != v.issuer {
+		return nil, errors.New("unexpected issuer")
+	}
+	if v.audience != "" && !claims.VerifyAudience(v.audience, true) {
+		return nil, errors.New("unexpected audience")
+	}
+	return claims, nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+var (
+	authConfig          = config.Default().Auth
+	defaultVerifier     *verifier
+	defaultVerifierOnce sync.Once
+)
+
+// UseAuth sets the JWT verification settings; main calls it before serving.
+func UseAuth(cfg config.Auth) {
+	authConfig = cfg
+}
+
+// jwtVerifier is built on first use so the settings passed to UseAuth are in place.
+func jwtVerifier() *verifier {
+	defaultVerifierOnce.Do(func() {
+		defaultVerifier = newVerifier(authConfig)
+	})
+	return defaultVerifier
+}
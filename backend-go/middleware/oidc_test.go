@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend-go/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func hs256Token(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestVerifierChecksClaims(t *testing.T) {
+	cfg := config.Default().Auth
+	cfg.HS256Secret = "test-secret"
+	cfg.Issuer = "https://idp.example.com/"
+	cfg.Audience = "clearstatus"
+	cfg.OrgClaim = "tenant"
+	v := newVerifier(cfg)
+	if v.orgClaim != "tenant" || v.roleClaim != "org_role" {
+		t.Errorf("claims = %q/%q, want tenant/org_role", v.orgClaim, v.roleClaim)
+	}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    "https://idp.example.com",
+			"aud":    "clearstatus",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"tenant": "org_1",
+		}
+	}
+	claims, err := v.verify(hs256Token(t, "test-secret", valid()))
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if got := stringClaim(claims, v.orgClaim); got != "org_1" {
+		t.Errorf("org claim = %q, want org_1", got)
+	}
+
+	cases := map[string]func(jwt.MapClaims){
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no exp":         func(c jwt.MapClaims) { delete(c, "exp") },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other" },
+	}
+	for name, mutate := range cases {
+		c := valid()
+		mutate(c)
+		if _, err := v.verify(hs256Token(t, "test-secret", c)); err == nil {
+			t.Errorf("%s token accepted", name)
+		}
+	}
+	if _, err := v.verify(hs256Token(t, "other-secret", valid())); err == nil {
+		t.Error("token signed with another secret accepted")
+	}
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := v.verify(none); err == nil {
+		t.Error("unsigned token accepted")
+	}
+}
+
+func TestVerifierNotConfigured(t *testing.T) {
+	v := newVerifier(config.Auth{})
+	if v.initErr != errAuthNotConfigured {
+		t.Fatalf("initErr = %v, want errAuthNotConfigured", v.initErr)
+	}
+	if _, err := v.verify(hs256Token(t, "x", jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})); err == nil {
+		t.Error("unconfigured verifier accepted a token")
+	}
+}
+
+func TestVerifierRetriesJWKSAtMostOncePerInterval(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	v := newVerifier(config.Auth{JWKSURL: srv.URL})
+	token := &jwt.Token{Header: map[string]interface{}{"kid": "k1", "alg": "RS256"}}
+	for i := 0; i < 3; i++ {
+		if _, err := v.keyfunc(token); err == nil {
+			t.Fatal("keyfunc succeeded without a JWKS")
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times within the retry interval, want 1", n)
+	}
+}
+
+func TestLoadJWKSSharesOneFetch(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	v := newVerifier(config.Auth{JWKSURL: srv.URL})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v.loadJWKS() == nil {
+				t.Error("expected the shared fetch to provide a JWKS")
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestLoadJWKSFailsFastAfterError(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	v := newVerifier(config.Auth{JWKSURL: srv.URL})
+	if v.loadJWKS() != nil {
+		t.Fatal("expected no JWKS from a failing endpoint")
+	}
+	start := time.Now()
+	if v.loadJWKS() != nil {
+		t.Fatal("expected no JWKS within the retry interval")
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("a request within the retry interval waited on the identity provider")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
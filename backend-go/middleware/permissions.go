@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"backend-go/db"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleResponder = "responder"
+	RoleViewer    = "viewer"
+
+	PermServicesRead     = "services:read"
+	PermServicesWrite    = "services:write"
+	PermServicesDelete   = "services:delete"
+	PermIncidentsRead    = "incidents:read"
+	PermIncidentsWrite   = "incidents:write"
+	PermIncidentsResolve = "incidents:resolve"
+	PermIncidentsDelete  = "incidents:delete"
+	PermOrgManage        = "org:manage"
+)
+
+var allPermissions = []string{
+	PermServicesRead, PermServicesWrite, PermServicesDelete,
+	PermIncidentsRead, PermIncidentsWrite, PermIncidentsResolve, PermIncidentsDelete,
+	PermOrgManage,
+}
+
+var rolePermissions = map[string][]string{
+	RoleViewer:    {PermServicesRead, PermIncidentsRead},
+	RoleResponder: {PermServicesRead, PermServicesWrite, PermIncidentsRead, PermIncidentsWrite, PermIncidentsResolve},
+	RoleAdmin:     allPermissions,
+}
+
+// scopePermissions is what each API key scope grants.
+var scopePermissions = map[string][]string{
+	ScopeRead:           {PermServicesRead, PermIncidentsRead},
+	ScopeServicesWrite:  {PermServicesWrite, PermServicesDelete},
+	ScopeIncidentsWrite: {PermIncidentsWrite, PermIncidentsResolve, PermIncidentsDelete},
+	ScopeAdmin:          allPermissions,
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// roleFromClaim maps Clerk's org_role claim (e.g. "org:admin") to a local role.
+// Clerk's default "org:member" role, like any other unknown role, maps to ""
+// so the configured default role applies.
+func roleFromClaim(claim string) string {
+	switch strings.TrimPrefix(claim, "org:") {
+	case "admin":
+		return RoleAdmin
+	case "responder":
+		return RoleResponder
+	case "viewer":
+		return RoleViewer
+	}
+	return ""
+}
+
+// resolveRole prefers a local override, then the JWT claim, then the configured default role.
+func resolveRole(orgID, userID, claim string) string {
+	var role string
+	if userID != "" {
+		_ = db.DB.QueryRow(`SELECT role FROM org_roles WHERE organization_id = $1 AND user_id = $2`, orgID, userID).Scan(&role)
+	}
+	if role == "" {
+		role = roleFromClaim(claim)
+	}
+	if role == "" {
+		role = authConfig.DefaultRole
+	}
+	if !IsValidRole(role) {
+		role = RoleViewer
+	}
+	return role
+}
+
+// HasPermission reports whether the authenticated caller, a user with a role or
+// an API key with scopes, has perm.
+func HasPermission(c *gin.Context, perm string) bool {
+	var granted []string
+	if v, isKey := c.Get("apiKeyScopes"); isKey {
+		for _, scope := range v.([]string) {
+			granted = append(granted, scopePermissions[scope]...)
+		}
+	} else {
+		granted = rolePermissions[c.GetString("role")]
+	}
+	for _, p := range granted {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission aborts with 403, naming the missing permission, unless the caller has perm.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, perm) {
+			AbortForbidden(c, perm)
+			return
+		}
+		c.Next()
+	}
+}
+
+func AbortForbidden(c *gin.Context, perm string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing permission " + perm, "permission": perm})
+}
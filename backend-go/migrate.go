@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"backend-go/config"
+	"backend-go/db"
+	"backend-go/migrations"
+)
+
+const migrateUsage = `usage: backend-go migrate <command>
+
+  up [version]   apply pending migrations, optionally only up to version
+  down [steps]   revert the latest applied migrations (default 1)
+  status         list migrations and whether they are applied`
+
+// runMigrate implements the "migrate" subcommand and returns the exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	n := 0
+	if len(args) > 1 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 0 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+	}
+
+	m, err := db.NewMigrator(db.DB, migrations.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	var ran []db.Migration
+	switch args[0] {
+	case "up":
+		ran, err = m.Up(ctx, n)
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		ran, err = m.Down(ctx, n)
+	case "status":
+		var status []db.MigrationStatus
+		if status, err = m.Status(ctx); err == nil {
+			fmt.Println(db.FormatStatus(status))
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	for _, mig := range ran {
+		fmt.Printf("%s %03d_%s\n", args[0], mig.Version, mig.Name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	return 0
+}
+
+// migrateOnStart applies pending migrations unless MIGRATE_ON_START=false.
+func migrateOnStart(cfg *config.Config) error {
+	if !cfg.MigrateOnStart {
+		return nil
+	}
+	m, err := db.NewMigrator(db.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(context.Background(), 0)
+	return err
+}
@@ -0,0 +1,3 @@
+-- 001_create_services.down.sql
+
+DROP TABLE IF EXISTS services;
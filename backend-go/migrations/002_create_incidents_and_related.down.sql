@@ -0,0 +1,5 @@
+-- 002_create_incidents_and_related.down.sql
+
+DROP TABLE IF EXISTS incident_updates;
+DROP TABLE IF EXISTS incident_services;
+DROP TABLE IF EXISTS incidents;
@@ -0,0 +1,3 @@
+-- 003_create_service_status_history.down.sql
+
+DROP TABLE IF EXISTS service_status_history;
@@ -1,3 +1,5 @@
+-- 003_create_service_status_history.sql
+
 CREATE TABLE IF NOT EXISTS service_status_history (
     id UUID PRIMARY KEY,
     service_id UUID REFERENCES services(id) ON DELETE CASCADE,
@@ -0,0 +1,4 @@
+-- 004_create_webhooks.down.sql
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,3 @@
+-- 005_create_sms_subscribers.down.sql
+
+DROP TABLE IF EXISTS sms_subscribers;
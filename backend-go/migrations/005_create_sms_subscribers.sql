@@ -0,0 +1,16 @@
+-- 005_create_sms_subscribers.sql
+
+CREATE TABLE IF NOT EXISTS sms_subscribers (
+    id UUID PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    voice BOOLEAN NOT NULL DEFAULT false,
+    verified BOOLEAN NOT NULL DEFAULT false,
+    verification_code_hash TEXT,
+    verification_expires_at TIMESTAMPTZ,
+    verification_attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    UNIQUE (organization_id, phone_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_subscribers_org ON sms_subscribers (organization_id);
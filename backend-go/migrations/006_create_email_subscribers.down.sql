@@ -0,0 +1,5 @@
+-- 006_create_email_subscribers.down.sql
+
+DROP TABLE IF EXISTS pending_notifications;
+DROP TABLE IF EXISTS email_subscribers;
+DROP TABLE IF EXISTS notification_settings;
@@ -0,0 +1,32 @@
+-- 006_create_email_subscribers.sql
+
+CREATE TABLE IF NOT EXISTS notification_settings (
+    organization_id TEXT PRIMARY KEY,
+    default_delivery TEXT NOT NULL DEFAULT 'immediate' CHECK (default_delivery IN ('immediate', 'hourly', 'daily')),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS email_subscribers (
+    id UUID PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    -- NULL falls back to notification_settings.default_delivery
+    delivery TEXT CHECK (delivery IN ('immediate', 'hourly', 'daily')),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    quiet_hours_start TEXT,
+    quiet_hours_end TEXT,
+    last_digest_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    UNIQUE (organization_id, email)
+);
+
+CREATE TABLE IF NOT EXISTS pending_notifications (
+    id UUID PRIMARY KEY,
+    subscriber_id UUID REFERENCES email_subscribers(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_subscribers_org ON email_subscribers (organization_id);
+CREATE INDEX IF NOT EXISTS idx_pending_notifications_subscriber ON pending_notifications (subscriber_id, created_at);
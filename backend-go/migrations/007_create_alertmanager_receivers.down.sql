@@ -0,0 +1,4 @@
+-- 007_create_alertmanager_receivers.down.sql
+
+DROP TABLE IF EXISTS alertmanager_incidents;
+DROP TABLE IF EXISTS alertmanager_receivers;
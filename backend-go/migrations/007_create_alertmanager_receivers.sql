@@ -0,0 +1,22 @@
+-- 007_create_alertmanager_receivers.sql
+
+CREATE TABLE IF NOT EXISTS alertmanager_receivers (
+    id UUID PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    -- [{"matchers": ["service=\"api\"", "severity=~\"critical|page\""], "serviceId": "..."}]
+    routes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- One open incident per Alertmanager alert group
+CREATE TABLE IF NOT EXISTS alertmanager_incidents (
+    receiver_id UUID REFERENCES alertmanager_receivers(id) ON DELETE CASCADE,
+    group_key TEXT NOT NULL,
+    incident_id UUID REFERENCES incidents(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (receiver_id, group_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alertmanager_receivers_org ON alertmanager_receivers (organization_id);
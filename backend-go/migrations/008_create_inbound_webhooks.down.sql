@@ -0,0 +1,3 @@
+-- 008_create_inbound_webhooks.down.sql
+
+DROP TABLE IF EXISTS inbound_webhooks;
@@ -0,0 +1,16 @@
+-- 008_create_inbound_webhooks.sql
+
+-- mapping example (Uptime Kuma):
+-- {"action": "service_status", "service": "$.monitor.name", "status": "$.heartbeat.status",
+--  "message": "$.msg", "statusMap": {"0": "Major Outage", "1": "Operational"}}
+CREATE TABLE IF NOT EXISTS inbound_webhooks (
+    id UUID PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'custom',
+    token_hash TEXT NOT NULL,
+    mapping JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_webhooks_org ON inbound_webhooks (organization_id);
@@ -0,0 +1,3 @@
+-- 009_create_escalation_integrations.down.sql
+
+DROP TABLE IF EXISTS escalation_integrations;
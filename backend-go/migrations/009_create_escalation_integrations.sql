@@ -0,0 +1,16 @@
+-- 009_create_escalation_integrations.sql
+
+CREATE TABLE IF NOT EXISTS escalation_integrations (
+    id UUID PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('pagerduty', 'opsgenie')),
+    name TEXT NOT NULL,
+    -- NULL uses the provider's public API
+    url TEXT,
+    -- PagerDuty routing key or Opsgenie API key
+    credential TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_escalation_integrations_org ON escalation_integrations (organization_id);
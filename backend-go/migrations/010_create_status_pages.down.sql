@@ -0,0 +1,3 @@
+-- 010_create_status_pages.down.sql
+
+DROP TABLE IF EXISTS status_pages;
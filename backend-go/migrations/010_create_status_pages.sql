@@ -0,0 +1,8 @@
+-- 010_create_status_pages.sql
+
+-- Public slug under which an organization's status page and event stream are served
+CREATE TABLE IF NOT EXISTS status_pages (
+    organization_id TEXT PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
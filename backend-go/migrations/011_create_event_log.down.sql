@@ -0,0 +1,3 @@
+-- 011_create_event_log.down.sql
+
+DROP TABLE IF EXISTS event_log;
@@ -0,0 +1,14 @@
+-- 011_create_event_log.sql
+
+-- Real-time events, relayed between backend instances with NOTIFY clearstatus_events.
+-- Rows are only kept long enough for instances to catch up after a reconnect.
+CREATE TABLE IF NOT EXISTS event_log (
+    seq BIGSERIAL PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    data JSONB,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_log_created_at ON event_log (created_at);
@@ -0,0 +1,3 @@
+-- 012_add_event_log_related.down.sql
+
+ALTER TABLE event_log DROP COLUMN IF EXISTS related;
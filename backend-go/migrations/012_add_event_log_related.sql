@@ -0,0 +1,5 @@
+-- 012_add_event_log_related.sql
+
+-- IDs of other entities an event concerns (e.g. the services affected by an incident),
+-- used by WebSocket subscription filters
+ALTER TABLE event_log ADD COLUMN IF NOT EXISTS related TEXT[] NOT NULL DEFAULT '{}';
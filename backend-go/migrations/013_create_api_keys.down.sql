@@ -0,0 +1,3 @@
+-- 013_create_api_keys.down.sql
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,3 @@
+-- 014_create_org_roles.down.sql
+
+DROP TABLE IF EXISTS org_roles;
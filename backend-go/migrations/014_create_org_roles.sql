@@ -0,0 +1,10 @@
+-- 014_create_org_roles.sql
+
+-- Local role assignments; they take precedence over the org_role claim in the JWT
+CREATE TABLE IF NOT EXISTS org_roles (
+    organization_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'responder', 'viewer')),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (organization_id, user_id)
+);
@@ -0,0 +1,4 @@
+-- 015_create_audit_log.down.sql
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
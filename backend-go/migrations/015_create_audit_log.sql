@@ -0,0 +1,30 @@
+-- 015_create_audit_log.sql
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    -- 'user' (JWT sub) or 'api_key' (api_keys.id)
+    actor_type TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    -- {"field": {"before": ..., "after": ...}} for the fields that changed
+    changes JSONB NOT NULL DEFAULT '{}',
+    request_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_org_created ON audit_log (organization_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (organization_id, target_type, target_id);
+
+-- The audit log is append-only
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_immutable ON audit_log;
+CREATE TRIGGER audit_log_immutable BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
@@ -0,0 +1,4 @@
+-- 016_add_incident_search.down.sql
+
+ALTER TABLE incident_updates DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE incidents DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,15 @@
+-- 016_add_incident_search.sql
+
+-- Full-text search over incidents and their updates. The vectors are generated
+-- columns, so every write keeps them current.
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+ALTER TABLE incident_updates ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(message, '')), 'C')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_search ON incidents USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_incident_updates_search ON incident_updates USING GIN (search_vector);
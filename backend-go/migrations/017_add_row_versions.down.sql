@@ -0,0 +1,4 @@
+-- 017_add_row_versions.down.sql
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS version;
+ALTER TABLE services DROP COLUMN IF EXISTS version;
@@ -0,0 +1,6 @@
+-- 017_add_row_versions.sql
+
+-- Row versions for optimistic concurrency: every write increments the version,
+-- which clients read as the ETag and send back in If-Match.
+ALTER TABLE services ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- 018_add_archival.down.sql
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE services DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,10 @@
+-- 018_add_archival.sql
+
+-- Deleting a service or incident archives it: it disappears from active
+-- listings and public pages but keeps its history until the purge job removes
+-- it after the retention period.
+ALTER TABLE services ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_services_archived ON services (archived_at) WHERE archived_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_incidents_archived ON incidents (archived_at) WHERE archived_at IS NOT NULL;
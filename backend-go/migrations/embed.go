@@ -0,0 +1,8 @@
+// Package migrations embeds the schema migrations. Each version NNN_name has an
+// up migration, NNN_name.sql, and may have a down migration, NNN_name.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type APIKey struct {
+	ID             string     `json:"id"`
+	OrganizationID string     `json:"organizationId"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Key            string     `json:"key,omitempty"`
+	Scopes         []string   `json:"scopes"`
+	CreatedBy      *string    `json:"createdBy,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	LastUsedAt     *time.Time `json:"lastUsedAt"`
+	RevokedAt      *time.Time `json:"revokedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
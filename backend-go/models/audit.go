@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID             string          `json:"id"`
+	OrganizationID string          `json:"organizationId"`
+	ActorType      string          `json:"actorType"`
+	ActorID        string          `json:"actorId"`
+	Action         string          `json:"action"`
+	TargetType     string          `json:"targetType"`
+	TargetID       string          `json:"targetId"`
+	Changes        json.RawMessage `json:"changes"`
+	RequestID      *string         `json:"requestId"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
@@ -12,6 +12,8 @@ type Incident struct {
 	OrganizationID string    `json:"organizationId"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
+	Version        int       `json:"version"`
+	ArchivedAt     *time.Time `json:"archivedAt,omitempty"`
 	Services       []Service `json:"services,omitempty"`
 	Updates        []IncidentUpdate `json:"updates,omitempty"`
 }
@@ -27,3 +29,21 @@ type IncidentUpdate struct {
 	Message    string    `json:"message"`
 	CreatedAt  time.Time `json:"createdAt"`
 }
+
+// IncidentSearchResult is an incident matching a full-text search.
+type IncidentSearchResult struct {
+	Incident   Incident         `json:"incident"`
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights are snippets of the matched fields; Update is taken from the
+// best matching update, if any matched. Snippets are HTML: the incident text is
+// escaped and matched terms are wrapped in <mark></mark>, the only tags they
+// contain, so clients can insert them as HTML without sanitizing.
+type SearchHighlights struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Update      *string `json:"update,omitempty"`
+	UpdateID    *string `json:"updateId,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// AlertRoute maps alerts whose labels satisfy every matcher to a service.
+type AlertRoute struct {
+	Matchers  []string `json:"matchers" binding:"max=20,dive,alert_matcher"`
+	ServiceID string   `json:"serviceId" binding:"required,uuid"`
+}
+
+type AlertmanagerReceiver struct {
+	ID             string       `json:"id"`
+	OrganizationID string       `json:"organizationId"`
+	Name           string       `json:"name"`
+	Token          string       `json:"token,omitempty"`
+	Routes         []AlertRoute `json:"routes"`
+	CreatedAt      time.Time    `json:"createdAt"`
+}
+
+// InboundMapping describes how to turn an arbitrary JSON payload into a status change.
+// Service, Status and Message are JSONPath expressions evaluated against the payload.
+type InboundMapping struct {
+	Action    string            `json:"action" binding:"required,oneof=service_status incident"`
+	Service   string            `json:"service" binding:"required_without=ServiceID,omitempty,jsonpath"`
+	ServiceID string            `json:"serviceId,omitempty"`
+	Status    string            `json:"status" binding:"required,jsonpath"`
+	Message   string            `json:"message,omitempty" binding:"omitempty,jsonpath"`
+	StatusMap map[string]string `json:"statusMap"`
+}
+
+type InboundWebhook struct {
+	ID             string         `json:"id"`
+	OrganizationID string         `json:"organizationId"`
+	Name           string         `json:"name"`
+	Source         string         `json:"source"`
+	Token          string         `json:"token,omitempty"`
+	Mapping        InboundMapping `json:"mapping"`
+	CreatedAt      time.Time      `json:"createdAt"`
+}
+
+type EscalationIntegration struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organizationId"`
+	Kind           string    `json:"kind"`
+	Name           string    `json:"name"`
+	URL            *string   `json:"url"`
+	Credential     string    `json:"credential,omitempty"`
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// OrgRole is a local role assignment that overrides the role from the identity provider.
+type OrgRole struct {
+	OrganizationID string    `json:"organizationId"`
+	UserID         string    `json:"userId"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -1,8 +1,17 @@
 package models
 
+import "time"
+
 type Service struct {
-	ID             string `json:"id"`
-	Name           string `json:"name"`
-	Status         string `json:"status"`
-	OrganizationID string `json:"organizationId"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`
+	OrganizationID string     `json:"organizationId"`
+	Version        int        `json:"version"`
+	ArchivedAt     *time.Time `json:"archivedAt,omitempty"`
+}
+
+type StatusChange struct {
+	Status    string    `json:"status"`
+	ChangedAt time.Time `json:"changedAt"`
 }
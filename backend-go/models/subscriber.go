@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type SMSSubscriber struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organizationId"`
+	PhoneNumber    string    `json:"phoneNumber"`
+	Voice          bool      `json:"voice"`
+	Verified       bool      `json:"verified"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type EmailSubscriber struct {
+	ID              string    `json:"id"`
+	OrganizationID  string    `json:"organizationId"`
+	Email           string    `json:"email"`
+	Delivery        *string   `json:"delivery"`
+	Timezone        string    `json:"timezone"`
+	QuietHoursStart *string   `json:"quietHoursStart"`
+	QuietHoursEnd   *string   `json:"quietHoursEnd"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type NotificationSettings struct {
+	OrganizationID  string `json:"organizationId"`
+	DefaultDelivery string `json:"defaultDelivery"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookEndpoint struct {
+	ID                  string     `json:"id"`
+	OrganizationID      string     `json:"organizationId"`
+	URL                 string     `json:"url"`
+	Secret              string     `json:"secret,omitempty"`
+	Events              []string   `json:"events"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+type WebhookDelivery struct {
+	ID         string          `json:"id"`
+	EndpointID string          `json:"endpointId"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"`
+	StatusCode *int            `json:"statusCode,omitempty"`
+	Success    bool            `json:"success"`
+	Error      *string         `json:"error,omitempty"`
+	DurationMs *int            `json:"durationMs,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// inFlight counts deliveries running in the background.
+var inFlight sync.WaitGroup
+
+// background runs fn in its own goroutine and lets Drain wait for it.
+func background(fn func()) {
+	inFlight.Add(1)
+	go func() {
+		defer inFlight.Done()
+		fn()
+	}()
+}
+
+// Drain waits until background email, webhook, escalation and SMS deliveries have
+// finished, or returns ctx's error when it expires first. Call it after the
+// HTTP server has stopped accepting requests.
+func Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"backend-go/db"
+	"backend-go/utils"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryHourly    = "hourly"
+	DeliveryDaily     = "daily"
+)
+
+func IsValidDelivery(delivery string) bool {
+	switch delivery {
+	case DeliveryImmediate, DeliveryHourly, DeliveryDaily:
+		return true
+	}
+	return false
+}
+
+// ParseClock parses an "HH:MM" quiet hours boundary into minutes after midnight.
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+type emailRecipient struct {
+	id         string
+	email      string
+	delivery   string
+	location   *time.Location
+	quietStart sql.NullString
+	quietEnd   sql.NullString
+	lastDigest sql.NullTime
+}
+
+// inQuietHours reports whether now falls inside the recipient's quiet window,
+// evaluated in their own time zone. Windows may wrap past midnight.
+func (r emailRecipient) inQuietHours(now time.Time) bool {
+	if !r.quietStart.Valid || !r.quietEnd.Valid {
+		return false
+	}
+	start, err1 := ParseClock(r.quietStart.String)
+	end, err2 := ParseClock(r.quietEnd.String)
+	if err1 != nil || err2 != nil || start == end {
+		return false
+	}
+	local := now.In(r.location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// digestDue reports whether a batched recipient should receive their queued notifications now.
+func (r emailRecipient) digestDue(now time.Time) bool {
+	if r.inQuietHours(now) {
+		return false
+	}
+	if !r.lastDigest.Valid {
+		return true
+	}
+	switch r.delivery {
+	case DeliveryHourly:
+		return now.Sub(r.lastDigest.Time) >= time.Hour
+	case DeliveryDaily:
+		return now.Sub(r.lastDigest.Time) >= 24*time.Hour
+	}
+	// immediate recipients only have a queue while in quiet hours
+	return true
+}
+
+func loadRecipients(query string, args ...interface{}) ([]emailRecipient, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []emailRecipient
+	for rows.Next() {
+		var (
+			r  emailRecipient
+			tz string
+		)
+		if err := rows.Scan(&r.id, &r.email, &r.delivery, &tz, &r.quietStart, &r.quietEnd, &r.lastDigest); err != nil {
+			continue
+		}
+		r.location, err = time.LoadLocation(tz)
+		if err != nil {
+			r.location = time.UTC
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+var notifyTo []string
+
+// UseNotifyTo sets the addresses that receive every notification immediately.
+func UseNotifyTo(addrs []string) {
+	notifyTo = addrs
+}
+
+// recipientColumns treats a subscriber who has never had a digest as if their
+// last one went out when they subscribed, so the first arrives a full period later.
+const recipientColumns = `SELECT s.id, s.email, COALESCE(s.delivery, n.default_delivery, 'immediate'), s.timezone, s.quiet_hours_start, s.quiet_hours_end, COALESCE(s.last_digest_at, s.created_at)
+	FROM email_subscribers s LEFT JOIN notification_settings n ON n.organization_id = s.organization_id`
+
+// Email notifies the org's email subscribers. Subscribers on hourly or daily digests,
+// and immediate subscribers inside their quiet hours, get the message queued for their
+// next digest unless it is critical. Addresses in SMTP_NOTIFY_TO are always sent immediately.
+// Delivery happens in the background; callers never wait on the mail server.
+func Email(orgID, subject, body string, critical bool) {
+	background(func() { emailSubscribers(orgID, subject, body, critical) })
+}
+
+func emailSubscribers(orgID, subject, body string, critical bool) {
+	if len(notifyTo) > 0 {
+		_ = utils.SendEmail(notifyTo, subject, body)
+	}
+
+	recipients, err := loadRecipients(recipientColumns+` WHERE s.organization_id = $1`, orgID)
+	if err != nil {
+		slog.Error("could not load email subscribers", "err", err)
+		return
+	}
+
+	now := time.Now()
+	for _, r := range recipients {
+		if critical || (r.delivery == DeliveryImmediate && !r.inQuietHours(now)) {
+			if err := utils.SendEmail([]string{r.email}, subject, body); err != nil {
+				slog.Error("email to subscriber failed", "err", err)
+			}
+			continue
+		}
+		_, err := db.DB.Exec(`INSERT INTO pending_notifications (id, subscriber_id, subject, body) VALUES ($1, $2, $3, $4)`,
+			uuid.NewString(), r.id, subject, body)
+		if err != nil {
+			slog.Error("could not queue notification", "err", err)
+		}
+	}
+}
+
+// digestLockID is the pg_advisory_lock key held while flushing digests, so only
+// one instance sends them each interval.
+const digestLockID = 727362
+
+// FlushDigests sends queued notifications to every subscriber whose digest is due.
+// When another instance is already flushing it returns without doing anything.
+func FlushDigests() error {
+	ctx := context.Background()
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, digestLockID).Scan(&locked); err != nil {
+		return fmt.Errorf("acquire digest lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, digestLockID)
+
+	recipients, err := loadRecipients(recipientColumns + ` WHERE EXISTS (SELECT 1 FROM pending_notifications p WHERE p.subscriber_id = s.id)`)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, r := range recipients {
+		if !r.digestDue(now) {
+			continue
+		}
+		if err := sendDigest(r, now); err != nil {
+			slog.Error("digest failed", "subscriber_id", r.id, "err", err)
+		}
+	}
+	return nil
+}
+
+func sendDigest(r emailRecipient, now time.Time) error {
+	rows, err := db.DB.Query(`SELECT id, subject, body, created_at FROM pending_notifications WHERE subscriber_id = $1 AND created_at <= $2 ORDER BY created_at ASC`, r.id, now)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		ids  []string
+		body strings.Builder
+	)
+	for rows.Next() {
+		var (
+			id, subject, text string
+			createdAt         time.Time
+		)
+		if err := rows.Scan(&id, &subject, &text, &createdAt); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		fmt.Fprintf(&body, "%s - %s\n%s\n\n", createdAt.In(r.location).Format("Jan 2 15:04 MST"), subject, text)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	subject := fmt.Sprintf("[StatusPage] %d status updates", len(ids))
+	if len(ids) == 1 {
+		subject = "[StatusPage] 1 status update"
+	}
+	if err := utils.SendEmail([]string{r.email}, subject, body.String()); err != nil {
+		return err
+	}
+
+	_, _ = db.DB.Exec(`DELETE FROM pending_notifications WHERE id = ANY($1)`, pq.Array(ids))
+	_, _ = db.DB.Exec(`UPDATE email_subscribers SET last_digest_at = $1 WHERE id = $2`, now, r.id)
+	return nil
+}
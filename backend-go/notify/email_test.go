@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestDigestDue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(-d), Valid: true} }
+	quietStart := sql.NullString{String: "11:00", Valid: true}
+	quietEnd := sql.NullString{String: "13:00", Valid: true}
+
+	cases := []struct {
+		name string
+		r    emailRecipient
+		want bool
+	}{
+		{"hourly just subscribed", emailRecipient{delivery: DeliveryHourly, lastDigest: at(time.Minute)}, false},
+		{"hourly after an hour", emailRecipient{delivery: DeliveryHourly, lastDigest: at(time.Hour)}, true},
+		{"daily after an hour", emailRecipient{delivery: DeliveryDaily, lastDigest: at(time.Hour)}, false},
+		{"daily after a day", emailRecipient{delivery: DeliveryDaily, lastDigest: at(24 * time.Hour)}, true},
+		{"immediate queue after quiet hours", emailRecipient{delivery: DeliveryImmediate, lastDigest: at(time.Minute)}, true},
+		{"held during quiet hours", emailRecipient{delivery: DeliveryDaily, lastDigest: at(48 * time.Hour), quietStart: quietStart, quietEnd: quietEnd}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.r.location == nil {
+				tc.r.location = time.UTC
+			}
+			if got := tc.r.digestDue(now); got != tc.want {
+				t.Fatalf("digestDue = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInQuietHoursWrapsMidnight(t *testing.T) {
+	r := emailRecipient{
+		location:   time.UTC,
+		quietStart: sql.NullString{String: "22:00", Valid: true},
+		quietEnd:   sql.NullString{String: "07:00", Valid: true},
+	}
+	cases := map[string]bool{"23:30": true, "03:00": true, "07:00": false, "12:00": false}
+	for clock, want := range cases {
+		at, _ := time.Parse("15:04", clock)
+		if got := r.inQuietHours(at); got != want {
+			t.Errorf("inQuietHours(%s) = %v, want %v", clock, got, want)
+		}
+	}
+}
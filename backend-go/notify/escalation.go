@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"backend-go/db"
+	"backend-go/models"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	EscalationTrigger     = "trigger"
+	EscalationAcknowledge = "acknowledge"
+	EscalationResolve     = "resolve"
+
+	KindPagerDuty = "pagerduty"
+	KindOpsgenie  = "opsgenie"
+
+	defaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	defaultOpsgenieURL  = "https://api.opsgenie.com"
+)
+
+var escalationClient = &http.Client{Timeout: 10 * time.Second}
+
+type escalationTarget struct {
+	id         string
+	kind       string
+	url        sql.NullString
+	credential string
+}
+
+// DedupKey is the stable identifier shared by every page sent for one incident,
+// used as the PagerDuty dedup_key and the Opsgenie alias.
+func DedupKey(incidentID string) string {
+	return "clearstatus-" + incidentID
+}
+
+// Escalate pages the org's PagerDuty and Opsgenie integrations about an incident.
+// Scheduled maintenance never pages. Delivery happens in the background.
+func Escalate(orgID, action string, incident models.Incident) {
+	if incident.Type != "incident" {
+		return
+	}
+	background(func() { escalate(orgID, action, incident) })
+}
+
+func escalate(orgID, action string, incident models.Incident) {
+	rows, err := db.DB.Query(`SELECT id, kind, url, credential FROM escalation_integrations WHERE organization_id = $1 AND enabled`, orgID)
+	if err != nil {
+		slog.Error("could not load escalation integrations", "err", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t escalationTarget
+		if err := rows.Scan(&t.id, &t.kind, &t.url, &t.credential); err != nil {
+			continue
+		}
+		background(func() {
+			var err error
+			switch t.kind {
+			case KindPagerDuty:
+				err = sendPagerDuty(t, action, incident)
+			case KindOpsgenie:
+				err = sendOpsgenie(t, action, incident)
+			}
+			if err != nil {
+				slog.Error("escalation failed", "kind", t.kind, "action", action, "incident_id", incident.ID, "err", err)
+			}
+		})
+	}
+}
+
+func serviceNames(incident models.Incident) []string {
+	names := make([]string, 0, len(incident.Services))
+	for _, s := range incident.Services {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func hasMajorOutage(incident models.Incident) bool {
+	for _, s := range incident.Services {
+		if s.Status == "Major Outage" {
+			return true
+		}
+	}
+	return false
+}
+
+// sendPagerDuty posts a PagerDuty Events API v2 event.
+func sendPagerDuty(t escalationTarget, action string, incident models.Incident) error {
+	event := map[string]interface{}{
+		"routing_key":  t.credential,
+		"event_action": action,
+		"dedup_key":    DedupKey(incident.ID),
+	}
+	if action == EscalationTrigger {
+		severity := "error"
+		if hasMajorOutage(incident) {
+			severity = "critical"
+		}
+		event["payload"] = map[string]interface{}{
+			"summary":   truncate(incident.Title, 1024),
+			"source":    "ClearStatus",
+			"severity":  severity,
+			"component": strings.Join(serviceNames(incident), ", "),
+			"custom_details": map[string]interface{}{
+				"status":      incident.Status,
+				"description": incident.Description,
+				"services":    serviceNames(incident),
+			},
+		}
+	}
+
+	endpoint := defaultPagerDutyURL
+	if t.url.Valid && t.url.String != "" {
+		endpoint = t.url.String
+	}
+	return postJSON(endpoint, nil, event)
+}
+
+// sendOpsgenie creates, acknowledges or closes an Opsgenie alert keyed by alias.
+func sendOpsgenie(t escalationTarget, action string, incident models.Incident) error {
+	base := defaultOpsgenieURL
+	if t.url.Valid && t.url.String != "" {
+		base = t.url.String
+	}
+	base = strings.TrimRight(base, "/")
+	headers := map[string]string{"Authorization": "GenieKey " + t.credential}
+	alias := DedupKey(incident.ID)
+
+	switch action {
+	case EscalationTrigger:
+		priority := "P3"
+		if hasMajorOutage(incident) {
+			priority = "P1"
+		}
+		return postJSON(base+"/v2/alerts", headers, map[string]interface{}{
+			"message":     truncate(incident.Title, 130),
+			"alias":       alias,
+			"description": incident.Description,
+			"priority":    priority,
+			"source":      "ClearStatus",
+			"tags":        serviceNames(incident),
+			"details":     map[string]string{"status": incident.Status},
+		})
+	case EscalationAcknowledge:
+		return postJSON(base+"/v2/alerts/"+url.PathEscape(alias)+"/acknowledge?identifierType=alias", headers, map[string]interface{}{
+			"source": "ClearStatus",
+			"note":   "Incident updated: " + incident.Status,
+		})
+	case EscalationResolve:
+		return postJSON(base+"/v2/alerts/"+url.PathEscape(alias)+"/close?identifierType=alias", headers, map[string]interface{}{
+			"source": "ClearStatus",
+			"note":   "Incident resolved in ClearStatus",
+		})
+	}
+	return fmt.Errorf("unknown escalation action %q", action)
+}
+
+func postJSON(endpoint string, headers map[string]string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := escalationClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"backend-go/db"
+	"backend-go/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	smsPerNumberPerHour = 5
+	verificationTTL     = 10 * time.Minute
+)
+
+// ErrSMSRateLimited is returned when a number has already received its hourly allowance.
+var ErrSMSRateLimited = errors.New("sms rate limit exceeded for number")
+
+// ErrSMSNotConfigured is returned when no SMS provider credentials are set.
+var ErrSMSNotConfigured = errors.New("sms provider not configured")
+
+var (
+	smsProvider utils.SMSProvider
+	smsLimiter  = newRateLimiter(smsPerNumberPerHour, time.Hour)
+)
+
+// UseSMSProvider sets the provider for texts and calls; nil disables them.
+func UseSMSProvider(p utils.SMSProvider) {
+	smsProvider = p
+}
+
+func provider() utils.SMSProvider {
+	return smsProvider
+}
+
+// rateLimiter allows at most max events per key within a sliding window.
+// Keys whose events have all aged out are swept once per window so the map
+// does not grow with every number ever texted.
+type rateLimiter struct {
+	max       int
+	window    time.Duration
+	now       func() time.Time
+	lock      sync.Mutex
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+func newRateLimiter(max int, window time.Duration) *rateLimiter {
+	return &rateLimiter{max: max, window: window, now: time.Now, events: make(map[string][]time.Time)}
+}
+
+func (l *rateLimiter) Allow(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	cutoff := now.Add(-l.window)
+	if now.Sub(l.lastSweep) >= l.window {
+		l.sweep(cutoff)
+		l.lastSweep = now
+	}
+
+	recent := l.events[key][:0]
+	for _, t := range l.events[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= l.max {
+		l.events[key] = recent
+		return false
+	}
+	l.events[key] = append(recent, now)
+	return true
+}
+
+// sweep drops every key with no events after cutoff. Callers hold l.lock.
+func (l *rateLimiter) sweep(cutoff time.Time) {
+	for key, times := range l.events {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(l.events, key)
+		}
+	}
+}
+
+func sendSMS(to, body string) error {
+	p := provider()
+	if p == nil {
+		return ErrSMSNotConfigured
+	}
+	if !smsLimiter.Allow(to) {
+		return ErrSMSRateLimited
+	}
+	return p.SendSMS(to, body)
+}
+
+// NewVerificationCode returns a six digit code and the hash stored for it.
+func NewVerificationCode() (code, hash string, expiresAt time.Time, err error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	code = fmt.Sprintf("%06d", n.Int64())
+	return code, hashCode(code), time.Now().Add(verificationTTL), nil
+}
+
+// CheckVerificationCode compares a submitted code against its stored hash.
+func CheckVerificationCode(code, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashCode(code)), []byte(hash)) == 1
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendVerificationSMS texts a verification code to a newly added number.
+func SendVerificationSMS(to, code string) error {
+	return sendSMS(to, "Your ClearStatus verification code is "+code+". It expires in 10 minutes.")
+}
+
+// ServiceStatusSMS alerts verified subscribers when a service enters Major Outage.
+func ServiceStatusSMS(orgID, name, prevStatus, status string) {
+	if status != "Major Outage" || prevStatus == status {
+		return
+	}
+	background(func() { smsSubscribers(orgID, "[StatusPage] MAJOR OUTAGE: "+name+" is down.") })
+}
+
+// NewIncidentSMS alerts verified subscribers when an incident (not maintenance) is opened.
+func NewIncidentSMS(orgID, title, incidentType string) {
+	if incidentType != "incident" {
+		return
+	}
+	background(func() { smsSubscribers(orgID, "[StatusPage] New incident: "+title) })
+}
+
+func smsSubscribers(orgID, message string) {
+	if provider() == nil {
+		return
+	}
+	rows, err := db.DB.Query(`SELECT phone_number, voice FROM sms_subscribers WHERE organization_id = $1 AND verified`, orgID)
+	if err != nil {
+		slog.Error("could not load SMS subscribers", "err", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			phone string
+			voice bool
+		)
+		if err := rows.Scan(&phone, &voice); err != nil {
+			continue
+		}
+		if err := sendSMS(phone, message); err != nil {
+			slog.Error("SMS to subscriber failed", "err", err)
+			continue
+		}
+		if voice {
+			if err := provider().Call(phone, message); err != nil {
+				slog.Error("voice call to subscriber failed", "err", err)
+			}
+		}
+	}
+}
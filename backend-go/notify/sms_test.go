@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, time.Hour)
+	l.now = func() time.Time { return now }
+
+	if !l.Allow("+15550001") || !l.Allow("+15550001") {
+		t.Fatal("first two events should be allowed")
+	}
+	if l.Allow("+15550001") {
+		t.Fatal("third event inside the window should be refused")
+	}
+	if !l.Allow("+15550002") {
+		t.Fatal("limits are per key")
+	}
+
+	now = now.Add(time.Hour + time.Second)
+	if !l.Allow("+15550001") {
+		t.Fatal("events outside the window should no longer count")
+	}
+}
+
+func TestRateLimiterSweepsIdleKeys(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(5, time.Hour)
+	l.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.Allow(key)
+	}
+	now = now.Add(2 * time.Hour)
+	l.Allow("d")
+
+	if len(l.events) != 1 {
+		t.Fatalf("expected idle keys to be swept, %d keys remain", len(l.events))
+	}
+	if _, ok := l.events["d"]; !ok {
+		t.Fatal("the active key should be kept")
+	}
+}
@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"backend-go/db"
+	"backend-go/utils"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Endpoints are disabled after this many events in a row could not be delivered.
+	maxConsecutiveFailures = 10
+	maxDeliveryAttempts    = 3
+)
+
+// webhookClient only connects to publicly routable addresses. The check runs on
+// the resolved address of each connection, so it also covers redirects and DNS
+// names that point into the private network.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error { return checkWebhookAddr(address) },
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+var errPrivateAddress = errors.New("webhook target is not a public address")
+
+// checkWebhookAddr is replaced in tests, whose receivers listen on loopback.
+var checkWebhookAddr = publicAddress
+
+// publicAddress rejects loopback, private, link-local and unspecified addresses,
+// so an endpoint URL cannot be used to reach the backend's own network.
+func publicAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errPrivateAddress, ip)
+	}
+	return nil
+}
+
+// retry delays between delivery attempts of a single event
+var retryBackoff = []time.Duration{2 * time.Second, 10 * time.Second}
+
+type webhookPayload struct {
+	ID             string      `json:"id"`
+	Event          string      `json:"event"`
+	OrganizationID string      `json:"organizationId"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	Data           interface{} `json:"data"`
+}
+
+type webhookTarget struct {
+	id     string
+	url    string
+	secret string
+}
+
+// SignWebhook returns the value of the X-ClearStatus-Signature header for a body
+// sent at the given unix timestamp. Receivers recompute it over "<timestamp>.<body>".
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhooks delivers an event to every enabled endpoint of the org subscribed to it.
+// Delivery happens in the background; callers never wait on remote endpoints.
+func Webhooks(orgID, event string, data interface{}) {
+	background(func() { deliverWebhooks(orgID, event, data) })
+}
+
+func deliverWebhooks(orgID, event string, data interface{}) {
+	rows, err := db.DB.Query(`SELECT id, url, secret FROM webhook_endpoints
+		WHERE organization_id = $1 AND enabled AND (cardinality(events) = 0 OR $2 = ANY(events))`, orgID, event)
+	if err != nil {
+		slog.Error("could not load webhook endpoints", "err", err)
+		return
+	}
+	defer rows.Close()
+
+	var targets []webhookTarget
+	for rows.Next() {
+		var t webhookTarget
+		if err := rows.Scan(&t.id, &t.url, &t.secret); err == nil {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		ID:             uuid.NewString(),
+		Event:          event,
+		OrganizationID: orgID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           data,
+	})
+	if err != nil {
+		slog.Error("could not encode webhook payload", "err", err)
+		return
+	}
+
+	for _, t := range targets {
+		background(func() { deliverWebhook(t, event, body) })
+	}
+}
+
+func deliverWebhook(t webhookTarget, event string, body []byte) {
+	for attempt := 1; attempt <= maxDeliveryAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(retryBackoff[attempt-2])
+		}
+		if sendWebhook(t, event, body, attempt) {
+			_, _ = db.DB.Exec(`UPDATE webhook_endpoints SET consecutive_failures = 0 WHERE id = $1`, t.id)
+			return
+		}
+	}
+
+	var failures int
+	err := db.DB.QueryRow(`UPDATE webhook_endpoints SET consecutive_failures = consecutive_failures + 1 WHERE id = $1 RETURNING consecutive_failures`, t.id).Scan(&failures)
+	if err != nil {
+		slog.Error("could not record webhook failure", "err", err)
+		return
+	}
+	if failures >= maxConsecutiveFailures {
+		_, _ = db.DB.Exec(`UPDATE webhook_endpoints SET enabled = false, disabled_at = now(), updated_at = now() WHERE id = $1`, t.id)
+		slog.Warn("webhook endpoint disabled after consecutive failures", "endpoint_id", t.id, "failures", failures)
+	}
+}
+
+// sendWebhook performs a single delivery attempt and records it in the delivery history.
+func sendWebhook(t webhookTarget, event string, body []byte, attempt int) bool {
+	var (
+		statusCode *int
+		errMsg     *string
+	)
+	start := time.Now()
+	ok := func() bool {
+		req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+		if err != nil {
+			msg := err.Error()
+			errMsg = &msg
+			return false
+		}
+		ts := time.Now().Unix()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "ClearStatus-Webhooks/1.0")
+		req.Header.Set("X-ClearStatus-Event", event)
+		req.Header.Set("X-ClearStatus-Timestamp", strconv.FormatInt(ts, 10))
+		req.Header.Set("X-ClearStatus-Signature", SignWebhook(t.secret, ts, body))
+
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			msg := err.Error()
+			errMsg = &msg
+			return false
+		}
+		resp.Body.Close()
+		statusCode = &resp.StatusCode
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			msg := fmt.Sprintf("unexpected status %d", resp.StatusCode)
+			errMsg = &msg
+			return false
+		}
+		return true
+	}()
+	duration := int(time.Since(start).Milliseconds())
+
+	_, err := db.DB.Exec(`INSERT INTO webhook_deliveries (id, endpoint_id, event, payload, attempt, status_code, success, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		uuid.NewString(), t.id, event, body, attempt, statusCode, ok, errMsg, duration)
+	if err != nil {
+		slog.Error("could not record webhook delivery", "err", err)
+	}
+	return ok
+}
+
+// NewWebhookSecret generates the shared secret used to sign an endpoint's payloads.
+func NewWebhookSecret() (string, error) {
+	return utils.RandomToken("whsec_", 24)
+}
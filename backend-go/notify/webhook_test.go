@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"backend-go/db/dbtest"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// HMAC-SHA256 of `1700000000.{"event":"ping"}` keyed with whsec_test
+	want := "sha256=aa8efe37b751e71157c508c5ac4acb1e9fe5225db98355dfc00f4b680afbc447"
+	if got := SignWebhook("whsec_test", 1700000000, []byte(`{"event":"ping"}`)); got != want {
+		t.Errorf("SignWebhook = %s, want %s", got, want)
+	}
+	if SignWebhook("whsec_other", 1700000000, []byte(`{"event":"ping"}`)) == want {
+		t.Error("signature does not depend on the secret")
+	}
+	if SignWebhook("whsec_test", 1700000001, []byte(`{"event":"ping"}`)) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34:443":     true,
+		"[2606:4700::1111]:443": true,
+		"127.0.0.1:80":          false,
+		"[::1]:80":              false,
+		"10.1.2.3:443":          false,
+		"172.16.0.1:443":        false,
+		"192.168.1.10:8080":     false,
+		"169.254.169.254:80":    false,
+		"[fe80::1]:80":          false,
+		"[fd00::1]:443":         false,
+		"[::ffff:127.0.0.1]:80": false,
+		"0.0.0.0:80":            false,
+		"not-an-address":        false,
+	}
+	for addr, public := range cases {
+		if err := publicAddress(addr); (err == nil) != public {
+			t.Errorf("publicAddress(%q) = %v, want public %v", addr, err, public)
+		}
+	}
+}
+
+func TestWebhookClientRefusesLoopback(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer srv.Close()
+
+	_, err := webhookClient.Get(srv.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("err = %v, want errPrivateAddress", err)
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatal("the request reached a loopback receiver")
+	}
+}
+
+// webhookReceiver answers with the given statuses in turn, checking each request's signature.
+func webhookReceiver(t *testing.T, secret string, statuses ...int) (*httptest.Server, *int32) {
+	allowPrivateAddresses(t)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-ClearStatus-Timestamp"), 10, 64)
+		if got := r.Header.Get("X-ClearStatus-Signature"); got != SignWebhook(secret, ts, body) {
+			t.Errorf("attempt %d: bad signature %q", n, got)
+		}
+		if r.Header.Get("X-ClearStatus-Event") != "service_updated" {
+			t.Errorf("attempt %d: event header = %q", n, r.Header.Get("X-ClearStatus-Event"))
+		}
+		w.WriteHeader(statuses[min(int(n), len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// allowPrivateAddresses lets deliveries reach loopback receivers for the rest of the test.
+func allowPrivateAddresses(t *testing.T) {
+	prev := checkWebhookAddr
+	checkWebhookAddr = func(string) error { return nil }
+	t.Cleanup(func() { checkWebhookAddr = prev })
+}
+
+// noBackoff retries immediately for the rest of the test.
+func noBackoff(t *testing.T) {
+	prev := retryBackoff
+	retryBackoff = make([]time.Duration, len(prev))
+	t.Cleanup(func() { retryBackoff = prev })
+}
+
+func insertEndpoint(t *testing.T, conn *sql.DB, url string, failures int) webhookTarget {
+	target := webhookTarget{id: uuid.NewString(), url: url, secret: "whsec_test"}
+	_, err := conn.Exec(`INSERT INTO webhook_endpoints (id, organization_id, url, secret, consecutive_failures) VALUES ($1, $2, $3, $4, $5)`,
+		target.id, "org_"+uuid.NewString(), url, target.secret, failures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func TestDeliverWebhookRetries(t *testing.T) {
+	conn := dbtest.Open(t)
+	noBackoff(t)
+	srv, calls := webhookReceiver(t, "whsec_test", http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent)
+	target := insertEndpoint(t, conn, srv.URL, 4)
+
+	deliverWebhook(target, "service_updated", []byte(`{"event":"service_updated"}`))
+
+	if atomic.LoadInt32(calls) != maxDeliveryAttempts {
+		t.Errorf("%d attempts, want %d", atomic.LoadInt32(calls), maxDeliveryAttempts)
+	}
+	var failures, deliveries, succeeded int
+	conn.QueryRow(`SELECT consecutive_failures FROM webhook_endpoints WHERE id = $1`, target.id).Scan(&failures)
+	conn.QueryRow(`SELECT count(*), count(*) FILTER (WHERE success) FROM webhook_deliveries WHERE endpoint_id = $1`, target.id).Scan(&deliveries, &succeeded)
+	if failures != 0 {
+		t.Errorf("consecutive_failures = %d, want it reset to 0", failures)
+	}
+	if deliveries != 3 || succeeded != 1 {
+		t.Errorf("%d deliveries recorded, %d successful; want 3 and 1", deliveries, succeeded)
+	}
+}
+
+func TestDeliverWebhookDisablesFailingEndpoint(t *testing.T) {
+	conn := dbtest.Open(t)
+	noBackoff(t)
+	srv, calls := webhookReceiver(t, "whsec_test", http.StatusInternalServerError)
+	target := insertEndpoint(t, conn, srv.URL, maxConsecutiveFailures-2)
+
+	var (
+		enabled  bool
+		disabled sql.NullTime
+	)
+	endpoint := func() {
+		conn.QueryRow(`SELECT enabled, disabled_at FROM webhook_endpoints WHERE id = $1`, target.id).Scan(&enabled, &disabled)
+	}
+
+	deliverWebhook(target, "service_updated", []byte(`{}`))
+	if endpoint(); !enabled || disabled.Valid {
+		t.Fatalf("endpoint disabled after %d failures", maxConsecutiveFailures-1)
+	}
+	deliverWebhook(target, "service_updated", []byte(`{}`))
+	if endpoint(); enabled || !disabled.Valid {
+		t.Errorf("endpoint still enabled after %d failures", maxConsecutiveFailures)
+	}
+	if atomic.LoadInt32(calls) != 2*maxDeliveryAttempts {
+		t.Errorf("%d attempts, want %d", atomic.LoadInt32(calls), 2*maxDeliveryAttempts)
+	}
+}
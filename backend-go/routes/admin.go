@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"backend-go/logging"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes registers instance-wide operator endpoints; rg must use AdminTokenAuth.
+func RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/log-level", getLogLevel)
+	rg.PUT("/log-level", setLogLevel)
+}
+
+// GET /admin/log-level
+func getLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(logging.Level.Level().String())})
+}
+
+// PUT /admin/log-level (takes effect immediately on this instance)
+func setLogLevel(c *gin.Context) {
+	var input struct {
+		Level string `json:"level" binding:"required,oneof=debug info warn error"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+	if err := logging.SetLevel(input.Level); err != nil {
+		writeFieldErrors(c, fieldError{Field: "level", Code: "oneof", Message: err.Error()})
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "log level changed", "level", input.Level)
+	getLogLevel(c)
+}
@@ -0,0 +1,429 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/store"
+	"backend-go/utils"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func RegisterAlertmanagerRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/alertmanager-receivers", getAlertmanagerReceivers)
+	g.POST("/alertmanager-receivers", createAlertmanagerReceiver)
+	g.PUT("/alertmanager-receivers/:id", updateAlertmanagerReceiver)
+	g.DELETE("/alertmanager-receivers/:id", deleteAlertmanagerReceiver)
+}
+
+// RegisterAlertmanagerHooks registers the inbound endpoint Alertmanager posts to.
+// It authenticates with the receiver's own bearer token rather than a user JWT.
+func RegisterAlertmanagerHooks(rg *gin.RouterGroup) {
+	rg.POST("/hooks/alertmanager/:id", receiveAlertmanager)
+}
+
+// alertmanagerPayload is the body of Alertmanager's webhook_config notifications (version 4).
+type alertmanagerPayload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey" binding:"required"`
+	Status            string            `json:"status" binding:"required,oneof=firing resolved"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		Fingerprint string            `json:"fingerprint"`
+	} `json:"alerts"`
+}
+
+type labelMatcher struct {
+	name  string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+var matcherSyntax = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"?(.*?)"?\s*$`)
+
+// parseMatcher parses an Alertmanager-style matcher such as severity=~"critical|page".
+func parseMatcher(s string) (labelMatcher, error) {
+	m := matcherSyntax.FindStringSubmatch(s)
+	if m == nil {
+		return labelMatcher{}, fmt.Errorf("invalid matcher %q", s)
+	}
+	lm := labelMatcher{name: m[1], op: m[2], value: m[3]}
+	if lm.op == "=~" || lm.op == "!~" {
+		re, err := regexp.Compile("^(?:" + lm.value + ")$")
+		if err != nil {
+			return labelMatcher{}, fmt.Errorf("invalid regex in matcher %q", s)
+		}
+		lm.re = re
+	}
+	return lm, nil
+}
+
+func (m labelMatcher) matches(labels map[string]string) bool {
+	v := labels[m.name]
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	case "=~":
+		return m.re.MatchString(v)
+	default:
+		return !m.re.MatchString(v)
+	}
+}
+
+// alertRouteRefs locates the routes' service IDs for checkServiceRefs.
+func alertRouteRefs(routes []models.AlertRoute) []serviceRef {
+	refs := make([]serviceRef, len(routes))
+	for i, r := range routes {
+		refs[i] = serviceRef{field: fmt.Sprintf("routes[%d].serviceId", i), id: r.ServiceID}
+	}
+	return refs
+}
+
+// matchServices returns the IDs of services routed to by any of the given alert label sets.
+func matchServices(routes []models.AlertRoute, labelSets []map[string]string) []string {
+	seen := map[string]bool{}
+	for _, r := range routes {
+		var matchers []labelMatcher
+		for _, raw := range r.Matchers {
+			if m, err := parseMatcher(raw); err == nil {
+				matchers = append(matchers, m)
+			}
+		}
+		for _, labels := range labelSets {
+			ok := true
+			for _, m := range matchers {
+				if !m.matches(labels) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				seen[r.ServiceID] = true
+				break
+			}
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// orgServiceIDs drops any IDs that do not belong to the org or are archived.
+func orgServiceIDs(orgID string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+	rows, err := db.DB.Query(`SELECT id FROM services WHERE organization_id = $1 AND archived_at IS NULL AND id::text = ANY($2) ORDER BY id`, orgID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	owned := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			owned = append(owned, id)
+		}
+	}
+	return owned, rows.Err()
+}
+
+// GET /alertmanager-receivers (tokens are only returned on creation)
+func getAlertmanagerReceivers(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, name, routes, created_at FROM alertmanager_receivers WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch receivers"})
+		return
+	}
+	defer rows.Close()
+
+	receivers := []models.AlertmanagerReceiver{}
+	for rows.Next() {
+		var (
+			r      models.AlertmanagerReceiver
+			routes []byte
+		)
+		if err := rows.Scan(&r.ID, &r.OrganizationID, &r.Name, &routes, &r.CreatedAt); err == nil {
+			_ = json.Unmarshal(routes, &r.Routes)
+			receivers = append(receivers, r)
+		}
+	}
+	c.JSON(http.StatusOK, receivers)
+}
+
+// POST /alertmanager-receivers
+func createAlertmanagerReceiver(c *gin.Context) {
+	var input struct {
+		Name   string              `json:"name" binding:"required,max=100"`
+		Routes []models.AlertRoute `json:"routes" binding:"max=100,dive"`
+	}
+	if !bindBody(c, &input) || !checkServiceRefs(c, alertRouteRefs(input.Routes)...) {
+		return
+	}
+	if input.Routes == nil {
+		input.Routes = []models.AlertRoute{}
+	}
+
+	token, err := utils.RandomToken("am_", 24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	r := models.AlertmanagerReceiver{
+		ID:             uuid.NewString(),
+		OrganizationID: c.GetString("organizationId"),
+		Name:           input.Name,
+		Token:          token,
+		Routes:         input.Routes,
+	}
+	routes, _ := json.Marshal(r.Routes)
+	err = db.DB.QueryRow(`INSERT INTO alertmanager_receivers (id, organization_id, name, token_hash, routes) VALUES ($1, $2, $3, $4, $5) RETURNING created_at`,
+		r.ID, r.OrganizationID, r.Name, utils.HashToken(token), routes).Scan(&r.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create receiver"})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// PUT /alertmanager-receivers/:id
+func updateAlertmanagerReceiver(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	var input struct {
+		Name   string              `json:"name" binding:"required,max=100"`
+		Routes []models.AlertRoute `json:"routes" binding:"max=100,dive"`
+	}
+	if !bindBody(c, &input) || !checkServiceRefs(c, alertRouteRefs(input.Routes)...) {
+		return
+	}
+	if input.Routes == nil {
+		input.Routes = []models.AlertRoute{}
+	}
+
+	routes, _ := json.Marshal(input.Routes)
+	res, err := db.DB.Exec(`UPDATE alertmanager_receivers SET name=$1, routes=$2 WHERE id=$3 AND organization_id=$4`, input.Name, routes, id, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update receiver"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receiver not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, models.AlertmanagerReceiver{ID: id, OrganizationID: orgID, Name: input.Name, Routes: input.Routes})
+}
+
+// DELETE /alertmanager-receivers/:id
+func deleteAlertmanagerReceiver(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`DELETE FROM alertmanager_receivers WHERE id=$1 AND organization_id=$2`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete receiver"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receiver not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// POST /hooks/alertmanager/:id
+// A firing group opens one incident (deduplicated by groupKey) and keeps its affected
+// services in sync; a resolved notification resolves that incident.
+func receiveAlertmanager(c *gin.Context) {
+	id := c.Param("id")
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	var (
+		orgID     string
+		tokenHash string
+		rawRoutes []byte
+	)
+	err := db.DB.QueryRow(`SELECT organization_id, token_hash, routes FROM alertmanager_receivers WHERE id = $1`, id).Scan(&orgID, &tokenHash, &rawRoutes)
+	if err != nil || subtle.ConstantTimeCompare([]byte(utils.HashToken(token)), []byte(tokenHash)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid receiver or token"})
+		return
+	}
+
+	var payload alertmanagerPayload
+	if !bindBody(c, &payload) {
+		return
+	}
+
+	var routes []models.AlertRoute
+	_ = json.Unmarshal(rawRoutes, &routes)
+	var labelSets []map[string]string
+	for _, a := range payload.Alerts {
+		if payload.Status == "resolved" || a.Status == "firing" {
+			labelSets = append(labelSets, a.Labels)
+		}
+	}
+	serviceIDs, err := orgServiceIDs(orgID, matchServices(routes, labelSets))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to map alerts to services"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	incidentID, err := stores.AlertGroups.Incident(ctx, id, payload.GroupKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up alert group"})
+		return
+	}
+	var existing *models.Incident
+	if incidentID != "" {
+		if inc, err := stores.Incidents.Get(ctx, orgID, incidentID); err == nil && !inc.IsResolved {
+			existing = &inc
+		}
+	}
+
+	switch payload.Status {
+	case "firing":
+		if existing == nil {
+			input := incidentInput{
+				Title:       alertTitle(payload),
+				Description: alertDescription(payload),
+				Type:        "incident",
+				Status:      "Investigating",
+				ServiceIDs:  serviceIDs,
+			}
+			incidentID, created, err := stores.AlertGroups.Open(ctx, orgID, id, payload.GroupKey, store.IncidentChange(input))
+			if err != nil {
+				slog.ErrorContext(ctx, "could not open incident for alert group", "err", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open incident"})
+				return
+			}
+			if created {
+				announceIncident(ctx, orgID, incidentID, input)
+				c.JSON(http.StatusOK, gin.H{"incidentId": incidentID, "action": "created"})
+				return
+			}
+			// a concurrent notification for the group opened it first
+			inc, err := stores.Incidents.Get(ctx, orgID, incidentID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load incident"})
+				return
+			}
+			existing = &inc
+		}
+
+		if sameServices(existing.Services, serviceIDs) {
+			c.JSON(http.StatusOK, gin.H{"incidentId": existing.ID, "action": "unchanged"})
+			return
+		}
+		_, err = reviseIncident(ctx, orgID, existing.ID, incidentInput{
+			Title:       existing.Title,
+			Description: existing.Description,
+			Type:        existing.Type,
+			Status:      existing.Status,
+			ServiceIDs:  serviceIDs,
+		}, existing.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident"})
+			return
+		}
+		_, _ = appendIncidentUpdate(ctx, orgID, existing.ID, fmt.Sprintf("Alertmanager reports %d firing alert(s) in this group.", len(labelSets)))
+		c.JSON(http.StatusOK, gin.H{"incidentId": existing.ID, "action": "updated"})
+
+	case "resolved":
+		if existing == nil {
+			c.JSON(http.StatusOK, gin.H{"action": "ignored"})
+			return
+		}
+		_, err = reviseIncident(ctx, orgID, existing.ID, incidentInput{
+			Title:       existing.Title,
+			Description: existing.Description,
+			Type:        existing.Type,
+			Status:      "Resolved",
+			IsResolved:  true,
+			ServiceIDs:  serviceIDsOf(existing.Services),
+		}, existing.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve incident"})
+			return
+		}
+		_, _ = appendIncidentUpdate(ctx, orgID, existing.ID, "Resolved automatically: Alertmanager reported all alerts in this group as resolved.")
+		_ = stores.AlertGroups.Release(ctx, id, payload.GroupKey, existing.ID)
+		c.JSON(http.StatusOK, gin.H{"incidentId": existing.ID, "action": "resolved"})
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown alert status", "code": codeInvalidBody})
+	}
+}
+
+func alertTitle(p alertmanagerPayload) string {
+	if s := p.CommonAnnotations["summary"]; s != "" {
+		return s
+	}
+	if s := p.CommonLabels["alertname"]; s != "" {
+		return s
+	}
+	return "Alertmanager alert"
+}
+
+func alertDescription(p alertmanagerPayload) string {
+	desc := p.CommonAnnotations["description"]
+	if p.ExternalURL != "" {
+		if desc != "" {
+			desc += "\n\n"
+		}
+		desc += "Source: " + p.ExternalURL
+	}
+	return desc
+}
+
+func serviceIDsOf(services []models.Service) []string {
+	ids := make([]string, 0, len(services))
+	for _, s := range services {
+		ids = append(ids, s.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sameServices(services []models.Service, ids []string) bool {
+	current := serviceIDsOf(services)
+	if len(current) != len(ids) {
+		return false
+	}
+	for i := range current {
+		if current[i] != ids[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package routes
+
+import (
+	"backend-go/db/dbtest"
+	"backend-go/models"
+	"backend-go/store"
+	"backend-go/utils"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestParseMatcher(t *testing.T) {
+	cases := []struct {
+		in      string
+		name    string
+		op      string
+		value   string
+		matches []map[string]string
+		misses  []map[string]string
+	}{
+		{in: `severity="critical"`, name: "severity", op: "=", value: "critical",
+			matches: []map[string]string{{"severity": "critical"}},
+			misses:  []map[string]string{{"severity": "warning"}, {}}},
+		{in: ` job = api `, name: "job", op: "=", value: "api",
+			matches: []map[string]string{{"job": "api"}}},
+		{in: `env!="staging"`, name: "env", op: "!=", value: "staging",
+			matches: []map[string]string{{"env": "prod"}, {}},
+			misses:  []map[string]string{{"env": "staging"}}},
+		{in: `severity=~"critical|page"`, name: "severity", op: "=~", value: "critical|page",
+			matches: []map[string]string{{"severity": "critical"}, {"severity": "page"}},
+			misses:  []map[string]string{{"severity": "critical2"}, {"severity": "xpage"}}},
+		{in: `team!~"db.*"`, name: "team", op: "!~", value: "db.*",
+			matches: []map[string]string{{"team": "web"}, {}},
+			misses:  []map[string]string{{"team": "dba"}}},
+	}
+	for _, tc := range cases {
+		m, err := parseMatcher(tc.in)
+		if err != nil {
+			t.Errorf("parseMatcher(%q): %v", tc.in, err)
+			continue
+		}
+		if m.name != tc.name || m.op != tc.op || m.value != tc.value {
+			t.Errorf("parseMatcher(%q) = %s %s %q, want %s %s %q", tc.in, m.name, m.op, m.value, tc.name, tc.op, tc.value)
+		}
+		for _, labels := range tc.matches {
+			if !m.matches(labels) {
+				t.Errorf("%q does not match %v", tc.in, labels)
+			}
+		}
+		for _, labels := range tc.misses {
+			if m.matches(labels) {
+				t.Errorf("%q matches %v", tc.in, labels)
+			}
+		}
+	}
+
+	for _, in := range []string{"", "severity", `="critical"`, `1severity="critical"`, `severity=~"("`} {
+		if _, err := parseMatcher(in); err == nil {
+			t.Errorf("parseMatcher(%q) succeeded", in)
+		}
+	}
+}
+
+func TestMatchServices(t *testing.T) {
+	routes := []models.AlertRoute{
+		{ServiceID: "api", Matchers: []string{`service="api"`}},
+		{ServiceID: "db", Matchers: []string{`service=~"db|postgres"`, `severity!="info"`}},
+		{ServiceID: "all"},
+		{ServiceID: "also-all", Matchers: []string{}},
+	}
+	cases := []struct {
+		name      string
+		labelSets []map[string]string
+		want      []string
+	}{
+		{"no alerts", nil, []string{}},
+		{"one route and the catch-all", []map[string]string{{"service": "api"}}, []string{"all", "also-all", "api"}},
+		{"every matcher must hold", []map[string]string{{"service": "postgres", "severity": "info"}}, []string{"all", "also-all"}},
+		{"regex matcher", []map[string]string{{"service": "postgres", "severity": "critical"}}, []string{"all", "also-all", "db"}},
+		{"union over alerts", []map[string]string{{"service": "db"}, {"service": "api"}}, []string{"all", "also-all", "api", "db"}},
+		{"unrelated labels", []map[string]string{{"alertname": "Watchdog"}}, []string{"all", "also-all"}},
+		{"alert without labels", []map[string]string{{}}, []string{"all", "also-all"}},
+	}
+	for _, tc := range cases {
+		if got := matchServices(routes, tc.labelSets); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: matchServices = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := matchServices(nil, []map[string]string{{"service": "api"}}); len(got) != 0 {
+		t.Errorf("no routes: matchServices = %v, want none", got)
+	}
+}
+
+// alertmanagerFixture is a receiver routing every alert to one service.
+type alertmanagerFixture struct {
+	conn       *sql.DB
+	router     *gin.Engine
+	orgID      string
+	receiverID string
+	token      string
+	serviceID  string
+}
+
+func newAlertmanagerFixture(t *testing.T) alertmanagerFixture {
+	f := alertmanagerFixture{
+		conn:       dbtest.Open(t),
+		orgID:      "org_" + uuid.NewString(),
+		receiverID: uuid.NewString(),
+		token:      "am_test_" + uuid.NewString(),
+		serviceID:  uuid.NewString(),
+	}
+	_, err := f.conn.Exec(`INSERT INTO services (id, name, status, organization_id) VALUES ($1, 'API', 'Operational', $2)`, f.serviceID, f.orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, _ := json.Marshal([]models.AlertRoute{{ServiceID: f.serviceID}})
+	_, err = f.conn.Exec(`INSERT INTO alertmanager_receivers (id, organization_id, name, token_hash, routes) VALUES ($1, $2, 'prod', $3, $4)`,
+		f.receiverID, f.orgID, utils.HashToken(f.token), routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	f.router = gin.New()
+	UseStores(store.NewPostgres(f.conn))
+	RegisterAlertmanagerHooks(f.router.Group("/api"))
+	return f
+}
+
+// post sends an Alertmanager notification for group and returns the decoded response.
+func (f alertmanagerFixture) post(t *testing.T, token, group, status string) (int, map[string]string) {
+	body := `{"version":"4","groupKey":"` + group + `","status":"` + status + `","commonLabels":{"alertname":"HighErrorRate"},
+		"alerts":[{"status":"` + status + `","labels":{"alertname":"HighErrorRate","service":"api"}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/hooks/alertmanager/"+f.receiverID, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	var out map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	return w.Code, out
+}
+
+func TestAlertmanagerRejectsBadToken(t *testing.T) {
+	f := newAlertmanagerFixture(t)
+	if code, _ := f.post(t, "am_wrong", "{}:{alertname=\"x\"}", "firing"); code != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", code)
+	}
+}
+
+func TestAlertmanagerDeduplicatesByGroupKey(t *testing.T) {
+	f := newAlertmanagerFixture(t)
+	expect := func(status, group, action string) string {
+		t.Helper()
+		code, out := f.post(t, f.token, group, status)
+		if code != http.StatusOK || out["action"] != action {
+			t.Fatalf("%s %s: status %d, action %q, want %q", status, group, code, out["action"], action)
+		}
+		return out["incidentId"]
+	}
+
+	first := expect("firing", "group-a", "created")
+	if again := expect("firing", "group-a", "unchanged"); again != first {
+		t.Errorf("a repeated firing notification opened %s instead of reusing %s", again, first)
+	}
+	if other := expect("firing", "group-b", "created"); other == first {
+		t.Error("another group reused the first group's incident")
+	}
+
+	if resolved := expect("resolved", "group-a", "resolved"); resolved != first {
+		t.Errorf("resolved %s, want %s", resolved, first)
+	}
+	var isResolved bool
+	var linked int
+	f.conn.QueryRow(`SELECT is_resolved FROM incidents WHERE id = $1`, first).Scan(&isResolved)
+	f.conn.QueryRow(`SELECT count(*) FROM incident_services WHERE incident_id = $1 AND service_id = $2`, first, f.serviceID).Scan(&linked)
+	if !isResolved || linked != 1 {
+		t.Errorf("incident resolved = %v with %d link(s) to the routed service; want true and 1", isResolved, linked)
+	}
+	expect("resolved", "group-a", "ignored")
+
+	if reopened := expect("firing", "group-a", "created"); reopened == first {
+		t.Error("firing after resolution reopened the resolved incident instead of opening a new one")
+	}
+}
+
+func TestAlertmanagerConcurrentFiringOpensOneIncident(t *testing.T) {
+	f := newAlertmanagerFixture(t)
+	const n = 8
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code, out := f.post(t, f.token, "group-race", "firing"); code == http.StatusOK {
+				ids[i] = out["incidentId"]
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		if id == "" || id != ids[0] {
+			t.Fatalf("concurrent notifications got incidents %v, want one shared incident", ids)
+		}
+	}
+	var opened int
+	f.conn.QueryRow(`SELECT count(*) FROM incidents WHERE organization_id = $1`, f.orgID).Scan(&opened)
+	if opened != 1 {
+		t.Errorf("%d incidents opened, want 1", opened)
+	}
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/utils"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func RegisterAPIKeyRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/api-keys", getAPIKeys)
+	g.POST("/api-keys", createAPIKey)
+	g.DELETE("/api-keys/:id", revokeAPIKey)
+}
+
+// GET /api-keys (includes revoked and expired keys; the secret is never returned)
+func getAPIKeys(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, name, prefix, scopes, created_by, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys WHERE organization_id = $1 ORDER BY created_at DESC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.OrganizationID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.CreatedBy, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt); err == nil {
+			keys = append(keys, k)
+		}
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// POST /api-keys (the full key is only returned in this response)
+func createAPIKey(c *gin.Context) {
+	var input struct {
+		Name      string     `json:"name" binding:"required,max=100"`
+		Scopes    []string   `json:"scopes" binding:"required,min=1,dive,api_scope"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		writeFieldErrors(c, fieldError{Field: "expiresAt", Code: "future", Message: "expiresAt must be in the future"})
+		return
+	}
+
+	key, prefix, err := middleware.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+	k := models.APIKey{
+		ID:             uuid.NewString(),
+		OrganizationID: c.GetString("organizationId"),
+		Name:           input.Name,
+		Prefix:         prefix,
+		Key:            key,
+		Scopes:         input.Scopes,
+		ExpiresAt:      input.ExpiresAt,
+	}
+	if userID := c.GetString("userId"); userID != "" {
+		k.CreatedBy = &userID
+	}
+	err = db.DB.QueryRow(`INSERT INTO api_keys (id, organization_id, name, prefix, key_hash, scopes, created_by, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at`,
+		k.ID, k.OrganizationID, k.Name, k.Prefix, utils.HashToken(key), pq.Array(k.Scopes), k.CreatedBy, k.ExpiresAt).Scan(&k.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	c.JSON(http.StatusOK, k)
+}
+
+// DELETE /api-keys/:id (revokes; the key stays listed for reference)
+func revokeAPIKey(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`UPDATE api_keys SET revoked_at = now() WHERE id=$1 AND organization_id=$2 AND revoked_at IS NULL`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active API key not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": true, "id": id})
+}
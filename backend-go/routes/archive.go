@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PurgeArchived hard-deletes services and incidents archived longer than
+// retention, along with their history and timelines.
+func PurgeArchived(retention time.Duration) error {
+	ctx := context.Background()
+	cutoff := time.Now().Add(-retention)
+
+	incidents, err := stores.Incidents.Purge(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	services, err := stores.Services.Purge(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if incidents > 0 || services > 0 {
+		slog.Info("purged archived records", "incidents", incidents, "services", services, "cutoff", cutoff)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"backend-go/middleware"
+	"backend-go/models"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAuditRoutes(rg *gin.RouterGroup) {
+	rg.GET("/audit-log", middleware.RequirePermission(middleware.PermOrgManage), getAuditLog)
+}
+
+// fieldChange is one entry of an audit diff.
+type fieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// recordAudit appends an entry for a change made by the caller. before and after
+// are the target's state around the change (nil when created or deleted); only
+// the top-level JSON fields that differ are stored. Failures are logged, not
+// returned, since the change itself has already been made.
+func recordAudit(c *gin.Context, action, targetType, targetID string, before, after interface{}) {
+	actorType, actorID := "user", c.GetString("userId")
+	if keyID := c.GetString("apiKeyId"); keyID != "" {
+		actorType, actorID = "api_key", keyID
+	}
+	if hookID := c.GetString("inboundWebhookId"); hookID != "" {
+		actorType, actorID = "integration", hookID
+	}
+
+	changes, err := json.Marshal(diffFields(before, after))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "could not encode audit diff", "err", err)
+		return
+	}
+	var requestID *string
+	if id := c.GetString("requestId"); id != "" {
+		requestID = &id
+	}
+
+	err = stores.Audit.Record(c.Request.Context(), models.AuditEntry{
+		OrganizationID: c.GetString("organizationId"),
+		ActorType:      actorType,
+		ActorID:        actorID,
+		Action:         action,
+		TargetType:     targetType,
+		TargetID:       targetID,
+		Changes:        changes,
+		RequestID:      requestID,
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "could not write audit log", "err", err)
+	}
+}
+
+// diffFields compares the JSON encodings of before and after field by field.
+func diffFields(before, after interface{}) map[string]fieldChange {
+	b, a := jsonFields(before), jsonFields(after)
+	diff := map[string]fieldChange{}
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(av, bv) {
+			diff[k] = fieldChange{Before: bv, After: a[k]}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			diff[k] = fieldChange{After: av}
+		}
+	}
+	return diff
+}
+
+func jsonFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	raw, err := json.Marshal(v)
+	if err == nil {
+		_ = json.Unmarshal(raw, &fields)
+	}
+	return fields
+}
+
+// GET /audit-log?actor=&action=&targetType=&targetId=&since=&until=&limit=&cursor=
+// (most recent first)
+func getAuditLog(c *gin.Context) {
+	q, err := auditQuery(c, c.GetString("organizationId"))
+	if err != nil {
+		writePage(c, "audit log", nil, "", err)
+		return
+	}
+	entries, next, err := stores.Audit.List(c.Request.Context(), q)
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+	writePage(c, "audit log", entries, next, err)
+}
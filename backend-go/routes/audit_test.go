@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"backend-go/middleware"
+	"backend-go/models"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDiffFields(t *testing.T) {
+	svc := models.Service{ID: "s1", Name: "API", Status: "Operational", OrganizationID: "org_1"}
+	renamed := svc
+	renamed.Name, renamed.Status = "Public API", "Major Outage"
+
+	cases := []struct {
+		name          string
+		before, after interface{}
+		want          map[string]fieldChange
+	}{
+		{"unchanged", svc, svc, map[string]fieldChange{}},
+		{"updated", svc, renamed, map[string]fieldChange{
+			"name":   {Before: "API", After: "Public API"},
+			"status": {Before: "Operational", After: "Major Outage"},
+		}},
+		{"created", nil, models.Service{ID: "s1", Name: "API"}, map[string]fieldChange{
+			"id":             {After: "s1"},
+			"name":           {After: "API"},
+			"status":         {After: ""},
+			"organizationId": {After: ""},
+			"version":        {After: float64(0)},
+		}},
+		{"deleted", map[string]interface{}{"id": "s1", "tags": []string{"a"}}, nil, map[string]fieldChange{
+			"id":   {Before: "s1"},
+			"tags": {Before: []interface{}{"a"}},
+		}},
+		{"nested values compare deeply",
+			map[string]interface{}{"tags": []string{"a", "b"}, "meta": map[string]int{"n": 1}},
+			map[string]interface{}{"tags": []string{"a", "b"}, "meta": map[string]int{"n": 2}},
+			map[string]fieldChange{"meta": {Before: map[string]interface{}{"n": float64(1)}, After: map[string]interface{}{"n": float64(2)}}}},
+		{"field removed", map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"a": 1}, map[string]fieldChange{
+			"b": {Before: float64(2)},
+		}},
+	}
+	for _, tc := range cases {
+		if got := diffFields(tc.before, tc.after); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: diffFields = %#v, want %#v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGetAuditLog(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleAdmin)
+	svc := a.service("API", "Operational")
+	if w := a.do(http.MethodPut, "/api/services/"+svc.ID, `{"name":"API","status":"Major Outage"}`, "If-Match", "*"); w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body)
+	}
+	a.service("Web", "Operational")
+
+	w := a.do(http.MethodGet, "/api/audit-log?targetType=service&targetId="+svc.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var page struct {
+		Items      []models.AuditEntry `json:"items"`
+		NextCursor *string             `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Action != "service.updated" || page.NextCursor != nil {
+		t.Errorf("page = %+v, want the service.updated entry and no next cursor", page)
+	}
+
+	if w := a.do(http.MethodGet, "/api/audit-log?action=incident.created", ""); w.Body.String() != `{"items":[],"next_cursor":null}` {
+		t.Errorf("empty page = %s", w.Body)
+	}
+}
+
+func TestGetAuditLogRejectsInvalidQuery(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleAdmin)
+	for _, query := range []string{"since=yesterday", "until=2026-01-02", "limit=ten"} {
+		w := a.do(http.MethodGet, "/api/audit-log?"+query, "")
+		var body map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusBadRequest || body["code"] != codeInvalidQuery {
+			t.Errorf("%s: status %d, code %q, want 400 %s", query, w.Code, body["code"], codeInvalidQuery)
+		}
+	}
+	if w := newTestAPI(t, middleware.RoleResponder).do(http.MethodGet, "/api/audit-log", ""); w.Code != http.StatusForbidden {
+		t.Errorf("responder: status %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func RegisterEscalationRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/escalations", getEscalations)
+	g.POST("/escalations", createEscalation)
+	g.PUT("/escalations/:id", updateEscalation)
+	g.DELETE("/escalations/:id", deleteEscalation)
+}
+
+type escalationInput struct {
+	Kind       string  `json:"kind" binding:"required,oneof=pagerduty opsgenie"`
+	Name       string  `json:"name" binding:"required,max=100"`
+	URL        *string `json:"url" binding:"omitempty,http_url"`
+	Credential string  `json:"credential" binding:"required"`
+	Enabled    *bool   `json:"enabled"`
+}
+
+// normalize treats an empty URL as the provider's default.
+func (in *escalationInput) normalize() {
+	if in.URL != nil && *in.URL == "" {
+		in.URL = nil
+	}
+}
+
+// GET /escalations (credentials are never returned)
+func getEscalations(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, kind, name, url, enabled, created_at FROM escalation_integrations WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch escalations"})
+		return
+	}
+	defer rows.Close()
+
+	escalations := []models.EscalationIntegration{}
+	for rows.Next() {
+		var e models.EscalationIntegration
+		if err := rows.Scan(&e.ID, &e.OrganizationID, &e.Kind, &e.Name, &e.URL, &e.Enabled, &e.CreatedAt); err == nil {
+			escalations = append(escalations, e)
+		}
+	}
+	c.JSON(http.StatusOK, escalations)
+}
+
+// POST /escalations
+func createEscalation(c *gin.Context) {
+	var input escalationInput
+	if !bindBody(c, &input) {
+		return
+	}
+	input.normalize()
+
+	e := models.EscalationIntegration{
+		ID:             uuid.NewString(),
+		OrganizationID: c.GetString("organizationId"),
+		Kind:           input.Kind,
+		Name:           input.Name,
+		URL:            input.URL,
+		Enabled:        input.Enabled == nil || *input.Enabled,
+	}
+	err := db.DB.QueryRow(`INSERT INTO escalation_integrations (id, organization_id, kind, name, url, credential, enabled) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`,
+		e.ID, e.OrganizationID, e.Kind, e.Name, e.URL, input.Credential, e.Enabled).Scan(&e.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create escalation"})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// PUT /escalations/:id
+func updateEscalation(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	var input escalationInput
+	if !bindBody(c, &input) {
+		return
+	}
+	input.normalize()
+	enabled := input.Enabled == nil || *input.Enabled
+
+	res, err := db.DB.Exec(`UPDATE escalation_integrations SET kind=$1, name=$2, url=$3, credential=$4, enabled=$5 WHERE id=$6 AND organization_id=$7`,
+		input.Kind, input.Name, input.URL, input.Credential, enabled, id, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Escalation not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, models.EscalationIntegration{ID: id, OrganizationID: orgID, Kind: input.Kind, Name: input.Name, URL: input.URL, Enabled: enabled})
+}
+
+// DELETE /escalations/:id
+func deleteEscalation(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`DELETE FROM escalation_integrations WHERE id=$1 AND organization_id=$2`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete escalation"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Escalation not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
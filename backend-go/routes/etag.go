@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a row version as an entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETag returns the version in an entity tag, or -1 when it is not one of ours.
+func parseETag(tag string) int {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return -1
+	}
+	v, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || v < 1 {
+		return -1
+	}
+	return v
+}
+
+// ifMatchVersion returns the version a write is conditional on, given the version
+// the resource has now: 0 without If-Match or with "If-Match: *", current when any
+// entity tag in the header names it, otherwise -1, which never matches.
+func ifMatchVersion(c *gin.Context, current int) int {
+	h := strings.TrimSpace(c.GetHeader("If-Match"))
+	if h == "" || h == "*" {
+		return 0
+	}
+	for _, tag := range strings.Split(h, ",") {
+		if parseETag(tag) == current {
+			return current
+		}
+	}
+	return -1
+}
+
+// requireIfMatch responds 428 to a write without If-Match, which would silently
+// overwrite whatever changed since the client read the resource.
+func requireIfMatch(c *gin.Context) bool {
+	if strings.TrimSpace(c.GetHeader("If-Match")) != "" {
+		return true
+	}
+	c.JSON(http.StatusPreconditionRequired, gin.H{
+		"error": "If-Match is required; send the ETag of the copy being replaced, or * to overwrite it",
+	})
+	return false
+}
+
+// writeVersioned responds with a resource and its ETag, or with 304 when
+// If-None-Match already names that version.
+func writeVersioned(c *gin.Context, version int, body interface{}) {
+	c.Header("ETag", etag(version))
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		if strings.TrimSpace(tag) == "*" || parseETag(tag) == version {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// writeConflict responds 412 to a write based on a stale version, with the
+// resource as it is now so the client can reapply its change.
+func writeConflict(c *gin.Context, what string, version int, current interface{}) {
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   what + " was changed by someone else; reload it and try again",
+		"version": version,
+		"current": current,
+	})
+}
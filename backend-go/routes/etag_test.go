@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseETag(t *testing.T) {
+	cases := map[string]int{
+		`"1"`:      1,
+		`"42"`:     42,
+		` "7" `:    7,
+		`W/"3"`:    3,
+		`1`:        -1,
+		`"0"`:      -1,
+		`"-2"`:     -1,
+		`"abc"`:    -1,
+		`"`:        -1,
+		``:         -1,
+		`"1", "2"`: -1,
+	}
+	for tag, want := range cases {
+		if got := parseETag(tag); got != want {
+			t.Errorf("parseETag(%q) = %d, want %d", tag, got, want)
+		}
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	for _, v := range []int{1, 9, 1234} {
+		if got := parseETag(etag(v)); got != v {
+			t.Errorf("parseETag(etag(%d)) = %d", v, got)
+		}
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	cases := []struct {
+		header string
+		want   int
+	}{
+		{"", 0},
+		{"*", 0},
+		{`"5"`, 5},
+		{`W/"5"`, 5},
+		{`"4"`, -1},
+		{`"nope"`, -1},
+		{`"3", "5"`, 5},
+		{`"5","6"`, 5},
+		{`"3", "4"`, -1},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+		if tc.header != "" {
+			c.Request.Header.Set("If-Match", tc.header)
+		}
+		if got := ifMatchVersion(c, 5); got != tc.want {
+			t.Errorf("ifMatchVersion(%q, 5) = %d, want %d", tc.header, got, tc.want)
+		}
+	}
+}
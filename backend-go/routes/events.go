@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"backend-go/events"
+	"backend-go/models"
+	"backend-go/notify"
+	"log/slog"
+)
+
+// Notifier tells everything outside the API about changes: real-time
+// subscribers, webhook endpoints, email and SMS subscribers and on-call
+// integrations. Deliveries other than Publish happen in the background.
+type Notifier interface {
+	Publish(e events.Event) error
+	Webhooks(orgID, event string, data interface{})
+	Email(orgID, subject, body string, critical bool)
+	ServiceStatusSMS(orgID, name, prevStatus, status string)
+	NewIncidentSMS(orgID, title, incidentType string)
+	Escalate(orgID, action string, incident models.Incident)
+}
+
+// liveNotifier delivers through the events and notify packages.
+type liveNotifier struct{}
+
+func (liveNotifier) Publish(e events.Event) error { return events.Publish(e) }
+
+func (liveNotifier) Webhooks(orgID, event string, data interface{}) {
+	notify.Webhooks(orgID, event, data)
+}
+
+func (liveNotifier) Email(orgID, subject, body string, critical bool) {
+	notify.Email(orgID, subject, body, critical)
+}
+
+func (liveNotifier) ServiceStatusSMS(orgID, name, prevStatus, status string) {
+	notify.ServiceStatusSMS(orgID, name, prevStatus, status)
+}
+
+func (liveNotifier) NewIncidentSMS(orgID, title, incidentType string) {
+	notify.NewIncidentSMS(orgID, title, incidentType)
+}
+
+func (liveNotifier) Escalate(orgID, action string, incident models.Incident) {
+	notify.Escalate(orgID, action, incident)
+}
+
+// notifier is used by the service and incident handlers; tests can install fakes.
+var notifier Notifier = liveNotifier{}
+
+// UseNotifier sets the notifier the handlers use.
+func UseNotifier(n Notifier) {
+	notifier = n
+}
+
+// emitEvent publishes a change to the org's real-time subscribers and webhook endpoints.
+// related lists other entities the change concerns, such as an incident's services.
+func emitEvent(orgID, event, id string, data interface{}, related ...string) {
+	if err := notifier.Publish(events.Event{Type: event, ID: id, OrganizationID: orgID, Data: data, Related: related}); err != nil {
+		slog.Error("could not publish event", "err", err)
+	}
+
+	notifier.Webhooks(orgID, event, data)
+}
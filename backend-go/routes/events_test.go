@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"backend-go/events"
+	"backend-go/models"
+	"sync"
+)
+
+// fakeNotifier records what the handlers sent instead of delivering it.
+type fakeNotifier struct {
+	lock        sync.Mutex
+	events      []events.Event
+	webhooks    []string
+	emails      []string
+	sms         []string
+	escalations []string
+}
+
+func (n *fakeNotifier) Publish(e events.Event) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.events = append(n.events, e)
+	return nil
+}
+
+func (n *fakeNotifier) Webhooks(orgID, event string, data interface{}) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.webhooks = append(n.webhooks, event)
+}
+
+func (n *fakeNotifier) Email(orgID, subject, body string, critical bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.emails = append(n.emails, subject)
+}
+
+// ServiceStatusSMS records the service name when it would text subscribers,
+// which notify does only on entering a major outage.
+func (n *fakeNotifier) ServiceStatusSMS(orgID, name, prevStatus, status string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if status == "Major Outage" && prevStatus != status {
+		n.sms = append(n.sms, name)
+	}
+}
+
+// NewIncidentSMS records the title when it would text subscribers, which
+// notify does for incidents but not maintenance.
+func (n *fakeNotifier) NewIncidentSMS(orgID, title, incidentType string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if incidentType == "incident" {
+		n.sms = append(n.sms, title)
+	}
+}
+
+func (n *fakeNotifier) Escalate(orgID, action string, incident models.Incident) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.escalations = append(n.escalations, action)
+}
+
+// eventTypes returns the published event types in order.
+func (n *fakeNotifier) eventTypes() []string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	var out []string
+	for _, e := range n.events {
+		out = append(out, e.Type)
+	}
+	return out
+}
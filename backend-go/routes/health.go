@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyTimeout bounds all readiness checks of one probe together.
+const readyTimeout = 2 * time.Second
+
+// HealthCheck returns why a dependency is unavailable, or nil.
+type HealthCheck func(ctx context.Context) error
+
+// RegisterHealthRoutes registers the unauthenticated probes: /livez answers
+// while the process serves requests, /readyz only while every check passes.
+// Failures are logged; the response only names the failing checks.
+func RegisterHealthRoutes(r gin.IRoutes, checks map[string]HealthCheck) {
+	r.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/readyz", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+		defer cancel()
+
+		status, results := http.StatusOK, make(map[string]string, len(checks))
+		for name, check := range checks {
+			if err := check(ctx); err != nil {
+				slog.WarnContext(ctx, "readiness check failed", "check", name, "err", err)
+				status, results[name] = http.StatusServiceUnavailable, "failing"
+				continue
+			}
+			results[name] = "ok"
+		}
+		if status != http.StatusOK {
+			c.JSON(status, gin.H{"status": "unavailable", "checks": results})
+			return
+		}
+		c.JSON(status, gin.H{"status": "ok", "checks": results})
+	})
+}
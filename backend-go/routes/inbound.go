@@ -0,0 +1,350 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/utils"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	inboundActionServiceStatus = "service_status"
+	inboundActionIncident      = "incident"
+)
+
+func RegisterInboundRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/inbound-webhooks", getInboundWebhooks)
+	g.POST("/inbound-webhooks", createInboundWebhook)
+	g.PUT("/inbound-webhooks/:id", updateInboundWebhook)
+	g.DELETE("/inbound-webhooks/:id", deleteInboundWebhook)
+}
+
+// RegisterInboundHooks registers the URL external tools post to. The integration
+// token is accepted as a bearer token or, for tools that cannot set headers, ?token=.
+func RegisterInboundHooks(rg *gin.RouterGroup) {
+	rg.POST("/hooks/inbound/:id", receiveInboundWebhook)
+}
+
+// checkInboundMapping responds 400 unless the statusMap targets statuses of the
+// mapping's action and a fixed serviceId names one of the org's services. The
+// rest of the mapping is checked by its binding tags.
+func checkInboundMapping(c *gin.Context, m models.InboundMapping) bool {
+	var fields []fieldError
+	for from, to := range m.StatusMap {
+		field := "mapping.statusMap." + from
+		if m.Action == inboundActionServiceStatus && !isValidStatus(to) {
+			fields = append(fields, fieldError{Field: field, Code: "service_status", Message: field + " " + ruleMessages["service_status"]})
+		}
+		if m.Action == inboundActionIncident && !isValidIncidentStatus(to) {
+			fields = append(fields, fieldError{Field: field, Code: "incident_status", Message: field + " " + ruleMessages["incident_status"]})
+		}
+	}
+	if m.ServiceID != "" {
+		_, err := findOrgService(c.GetString("organizationId"), m.ServiceID)
+		if err == sql.ErrNoRows {
+			fields = append(fields, fieldError{Field: "mapping.serviceId", Code: codeUnknownService, Message: "mapping.serviceId is not a service of this organization"})
+		} else if err != nil {
+			slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check services"})
+			return false
+		}
+	}
+	if len(fields) > 0 {
+		writeFieldErrors(c, fields...)
+		return false
+	}
+	return true
+}
+
+// GET /inbound-webhooks (tokens are only returned on creation)
+func getInboundWebhooks(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, name, source, mapping, created_at FROM inbound_webhooks WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inbound webhooks"})
+		return
+	}
+	defer rows.Close()
+
+	hooks := []models.InboundWebhook{}
+	for rows.Next() {
+		var (
+			h       models.InboundWebhook
+			mapping []byte
+		)
+		if err := rows.Scan(&h.ID, &h.OrganizationID, &h.Name, &h.Source, &mapping, &h.CreatedAt); err == nil {
+			_ = json.Unmarshal(mapping, &h.Mapping)
+			hooks = append(hooks, h)
+		}
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// POST /inbound-webhooks
+func createInboundWebhook(c *gin.Context) {
+	var input struct {
+		Name    string                `json:"name" binding:"required,max=100"`
+		Source  string                `json:"source" binding:"max=50"`
+		Mapping models.InboundMapping `json:"mapping"`
+	}
+	if !bindBody(c, &input) || !checkInboundMapping(c, input.Mapping) {
+		return
+	}
+	if input.Source == "" {
+		input.Source = "custom"
+	}
+
+	token, err := utils.RandomToken("ih_", 24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	h := models.InboundWebhook{
+		ID:             uuid.NewString(),
+		OrganizationID: c.GetString("organizationId"),
+		Name:           input.Name,
+		Source:         input.Source,
+		Token:          token,
+		Mapping:        input.Mapping,
+	}
+	mapping, _ := json.Marshal(h.Mapping)
+	err = db.DB.QueryRow(`INSERT INTO inbound_webhooks (id, organization_id, name, source, token_hash, mapping) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`,
+		h.ID, h.OrganizationID, h.Name, h.Source, utils.HashToken(token), mapping).Scan(&h.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create inbound webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, h)
+}
+
+// PUT /inbound-webhooks/:id
+func updateInboundWebhook(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	var input struct {
+		Name    string                `json:"name" binding:"required,max=100"`
+		Source  string                `json:"source" binding:"max=50"`
+		Mapping models.InboundMapping `json:"mapping"`
+	}
+	if !bindBody(c, &input) || !checkInboundMapping(c, input.Mapping) {
+		return
+	}
+	if input.Source == "" {
+		input.Source = "custom"
+	}
+
+	mapping, _ := json.Marshal(input.Mapping)
+	res, err := db.DB.Exec(`UPDATE inbound_webhooks SET name=$1, source=$2, mapping=$3 WHERE id=$4 AND organization_id=$5`, input.Name, input.Source, mapping, id, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update inbound webhook"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inbound webhook not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, models.InboundWebhook{ID: id, OrganizationID: orgID, Name: input.Name, Source: input.Source, Mapping: input.Mapping})
+}
+
+// DELETE /inbound-webhooks/:id
+func deleteInboundWebhook(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`DELETE FROM inbound_webhooks WHERE id=$1 AND organization_id=$2`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete inbound webhook"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inbound webhook not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// POST /hooks/inbound/:id
+func receiveInboundWebhook(c *gin.Context) {
+	id := c.Param("id")
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" {
+		token = c.Query("token")
+	}
+
+	var (
+		orgID      string
+		tokenHash  string
+		rawMapping []byte
+	)
+	err := db.DB.QueryRow(`SELECT organization_id, token_hash, mapping FROM inbound_webhooks WHERE id = $1`, id).Scan(&orgID, &tokenHash, &rawMapping)
+	if err != nil || subtle.ConstantTimeCompare([]byte(utils.HashToken(token)), []byte(tokenHash)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook or token"})
+		return
+	}
+	// changes made through the hook are audited with the hook as the actor
+	c.Set("organizationId", orgID)
+	c.Set("inboundWebhookId", id)
+
+	var mapping models.InboundMapping
+	if err := json.Unmarshal(rawMapping, &mapping); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid mapping"})
+		return
+	}
+
+	var payload interface{}
+	if !bindBody(c, &payload) {
+		return
+	}
+
+	serviceRef := mapping.ServiceID
+	if mapping.Service != "" {
+		if v, ok := utils.JSONPathString(payload, mapping.Service); ok && v != "" {
+			serviceRef = v
+		}
+	}
+	svc, err := findOrgService(orgID, serviceRef)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No service matches " + serviceRef})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up service"})
+		return
+	}
+
+	rawStatus, ok := utils.JSONPathString(payload, mapping.Status)
+	if !ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Status not found at " + mapping.Status})
+		return
+	}
+	status := rawStatus
+	if mapped, ok := mapping.StatusMap[rawStatus]; ok {
+		status = mapped
+	}
+	message, _ := utils.JSONPathString(payload, mapping.Message)
+
+	switch mapping.Action {
+	case inboundActionServiceStatus:
+		if !isValidStatus(status) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unmapped status " + rawStatus})
+			return
+		}
+		if svc.Status == status {
+			c.JSON(http.StatusOK, gin.H{"serviceId": svc.ID, "action": "unchanged"})
+			return
+		}
+		before, updated, err := reviseService(c.Request.Context(), orgID, svc.ID, svc.Name, status, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"serviceId": svc.ID, "action": "updated", "status": status})
+
+		recordAudit(c, "service.updated", "service", svc.ID, before, updated)
+
+	case inboundActionIncident:
+		if !isValidIncidentStatus(status) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unmapped status " + rawStatus})
+			return
+		}
+		action, incidentID, err := applyInboundIncident(c, orgID, svc, status, message)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"serviceId": svc.ID, "incidentId": incidentID, "action": action})
+	}
+}
+
+// findOrgService resolves a service by ID or, failing that, by case-insensitive name.
+func findOrgService(orgID, ref string) (models.Service, error) {
+	var s models.Service
+	err := db.DB.QueryRow(`SELECT id, name, status, organization_id FROM services
+		WHERE organization_id = $1 AND archived_at IS NULL AND (id::text = $2 OR lower(name) = lower($2))
+		ORDER BY (id::text = $2) DESC LIMIT 1`, orgID, ref).Scan(&s.ID, &s.Name, &s.Status, &s.OrganizationID)
+	return s, err
+}
+
+// applyInboundIncident posts to the open incident affecting svc, opening one if needed,
+// and resolves it when the mapped status is Resolved or Completed. Each write is audited.
+func applyInboundIncident(c *gin.Context, orgID string, svc models.Service, status, message string) (string, string, error) {
+	ctx := c.Request.Context()
+	var incidentID string
+	err := db.DB.QueryRow(`SELECT i.id FROM incidents i JOIN incident_services isv ON isv.incident_id = i.id
+		WHERE isv.service_id = $1 AND i.organization_id = $2 AND NOT i.is_resolved AND i.archived_at IS NULL
+		ORDER BY i.created_at DESC LIMIT 1`, svc.ID, orgID).Scan(&incidentID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", "", err
+	}
+	resolved := status == "Resolved" || status == "Completed"
+
+	if incidentID == "" {
+		if resolved {
+			return "ignored", "", nil
+		}
+		title := message
+		if title == "" {
+			title = svc.Name + " is experiencing issues"
+		}
+		id, err := openIncident(ctx, orgID, incidentInput{
+			Title:       title,
+			Description: message,
+			Type:        "incident",
+			Status:      status,
+			ServiceIDs:  []string{svc.ID},
+		})
+		if err != nil {
+			return "", "", err
+		}
+		recordAudit(c, "incident.created", "incident", id, nil, auditIncident(ctx, orgID, id))
+		return "created", id, nil
+	}
+
+	existing, err := stores.Incidents.Get(ctx, orgID, incidentID)
+	if err != nil {
+		return "", "", err
+	}
+	if existing.Status != status {
+		_, err = reviseIncident(ctx, orgID, existing.ID, incidentInput{
+			Title:       existing.Title,
+			Description: existing.Description,
+			Type:        existing.Type,
+			Status:      status,
+			IsResolved:  resolved,
+			ServiceIDs:  serviceIDsOf(existing.Services),
+		}, existing.Version)
+		if err != nil {
+			return "", "", err
+		}
+		action := "incident.updated"
+		if resolved {
+			action = "incident.resolved"
+		}
+		recordAudit(c, action, "incident", existing.ID, snapshotOf(existing), auditIncident(ctx, orgID, existing.ID))
+	}
+	if message != "" {
+		u, err := appendIncidentUpdate(ctx, orgID, existing.ID, message)
+		if err != nil {
+			return "", "", err
+		}
+		recordAudit(c, "incident.update_added", "incident", existing.ID, nil, u)
+	}
+	if resolved {
+		return "resolved", existing.ID, nil
+	}
+	return "updated", existing.ID, nil
+}
@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"backend-go/db/dbtest"
+	"backend-go/models"
+	"backend-go/store"
+	"backend-go/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestReceiveInboundWebhook(t *testing.T) {
+	conn := dbtest.Open(t)
+	orgID := "org_" + uuid.NewString()
+	serviceID := uuid.NewString()
+	hookID := uuid.NewString()
+	token := "ih_test_" + uuid.NewString()
+
+	_, err := conn.Exec(`INSERT INTO services (id, name, status, organization_id) VALUES ($1, 'API', 'Operational', $2)`, serviceID, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping, _ := json.Marshal(models.InboundMapping{
+		Action:    inboundActionServiceStatus,
+		Service:   "$.monitor.name",
+		Status:    "$.heartbeat.status",
+		Message:   "$.msg",
+		StatusMap: map[string]string{"0": "Major Outage", "1": "Operational"},
+	})
+	_, err = conn.Exec(`INSERT INTO inbound_webhooks (id, organization_id, name, source, token_hash, mapping) VALUES ($1, $2, 'kuma', 'uptime-kuma', $3, $4)`,
+		hookID, orgID, utils.HashToken(token), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	UseStores(store.NewPostgres(conn))
+	RegisterInboundHooks(router.Group("/api"))
+	post := func(query, bearer, heartbeat string) (int, map[string]string) {
+		t.Helper()
+		body := `{"monitor":{"name":"api"},"heartbeat":{"status":` + heartbeat + `},"msg":"probe"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/hooks/inbound/"+hookID+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var out map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return w.Code, out
+	}
+	serviceStatus := func() string {
+		var status string
+		conn.QueryRow(`SELECT status FROM services WHERE id = $1`, serviceID).Scan(&status)
+		return status
+	}
+
+	for _, tc := range []struct{ name, query, bearer string }{
+		{"no token", "", ""},
+		{"wrong bearer token", "", "ih_wrong"},
+		{"wrong query token", "?token=ih_wrong", ""},
+	} {
+		if code, _ := post(tc.query, tc.bearer, "0"); code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", tc.name, code)
+		}
+	}
+	if got := serviceStatus(); got != "Operational" {
+		t.Fatalf("unauthenticated requests changed the service to %s", got)
+	}
+
+	code, out := post("", token, "0")
+	if code != http.StatusOK || out["action"] != "updated" || out["status"] != "Major Outage" {
+		t.Fatalf("bearer token: status %d, %v", code, out)
+	}
+	if got := serviceStatus(); got != "Major Outage" {
+		t.Errorf("statusMap 0: service is %s, want Major Outage", got)
+	}
+
+	code, out = post("?token="+token, "", "1")
+	if code != http.StatusOK || out["action"] != "updated" || out["status"] != "Operational" {
+		t.Fatalf("query token: status %d, %v", code, out)
+	}
+	if got := serviceStatus(); got != "Operational" {
+		t.Errorf("statusMap 1: service is %s, want Operational", got)
+	}
+
+	if code, out = post("", token, "1"); code != http.StatusOK || out["action"] != "unchanged" {
+		t.Errorf("repeated status: status %d, %v", code, out)
+	}
+	if code, _ = post("", token, "2"); code != http.StatusUnprocessableEntity {
+		t.Errorf("unmapped status: status %d, want 422", code)
+	}
+
+	var audited int
+	conn.QueryRow(`SELECT count(*) FROM audit_log WHERE organization_id = $1 AND actor_type = 'integration' AND actor_id = $2
+		AND action = 'service.updated' AND target_id = $3`, orgID, hookID, serviceID).Scan(&audited)
+	if audited != 2 {
+		t.Errorf("%d service updates audited with the webhook as actor, want 2", audited)
+	}
+}
@@ -1,208 +1,439 @@
 package routes
 
 import (
-	"backend-go/db"
+	"backend-go/middleware"
 	"backend-go/models"
-	"log"
+	"backend-go/notify"
+	"backend-go/store"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
 	"net/http"
-
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"encoding/json"
-	"backend-go/utils"
-	"os"
-	"strings"
 )
 
 func RegisterIncidentRoutes(rg *gin.RouterGroup) {
-	rg.GET("/incidents", getIncidents)
-	rg.POST("/incidents", createIncident)
-	rg.PUT("/incidents/:id", updateIncident)
-	rg.POST("/incidents/:id/update", addIncidentUpdate)
+	rg.GET("/incidents", middleware.RequirePermission(middleware.PermIncidentsRead), getIncidents)
+	rg.GET("/incidents/search", middleware.RequirePermission(middleware.PermIncidentsRead), searchIncidents)
+	rg.POST("/incidents", middleware.RequirePermission(middleware.PermIncidentsWrite), createIncident)
+	// resolving additionally requires incidents:resolve, checked in the handler
+	rg.GET("/incidents/:id", middleware.RequirePermission(middleware.PermIncidentsRead), getIncident)
+	rg.PUT("/incidents/:id", middleware.RequirePermission(middleware.PermIncidentsWrite), updateIncident)
+	rg.PATCH("/incidents/:id", middleware.RequirePermission(middleware.PermIncidentsWrite), patchIncident)
+	rg.POST("/incidents/:id/update", middleware.RequirePermission(middleware.PermIncidentsWrite), addIncidentUpdate)
+	rg.DELETE("/incidents/:id", middleware.RequirePermission(middleware.PermIncidentsDelete), deleteIncident)
+	rg.POST("/incidents/:id/restore", middleware.RequirePermission(middleware.PermIncidentsDelete), restoreIncident)
 }
 
-// GET /incidents (org-scoped, with services and updates)
+// GET /incidents (org-scoped, with services and updates; paginated, see incidentQuery)
 func getIncidents(c *gin.Context) {
-	orgID := c.GetString("organizationId")
-	rows, err := db.DB.Query(`SELECT id, title, description, type, status, is_resolved, organization_id, created_at, updated_at FROM incidents WHERE organization_id = $1 ORDER BY created_at DESC`, orgID)
+	listIncidents(c, c.GetString("organizationId"))
+}
+
+// listIncidents serves a page of incidents; an empty orgID lists every organization.
+func listIncidents(c *gin.Context, orgID string) {
+	q, err := incidentQuery(c, orgID)
 	if err != nil {
-		log.Println("❌ DB error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
-		return
-	}
-	defer rows.Close()
-
-	var incidents []models.Incident
-	for rows.Next() {
-		var i models.Incident
-		if err := rows.Scan(&i.ID, &i.Title, &i.Description, &i.Type, &i.Status, &i.IsResolved, &i.OrganizationID, &i.CreatedAt, &i.UpdatedAt); err == nil {
-			// Fetch affected services
-			svcRows, _ := db.DB.Query(`SELECT s.id, s.name, s.status, s.organization_id FROM services s JOIN incident_services isv ON s.id = isv.service_id WHERE isv.incident_id = $1`, i.ID)
-			for svcRows.Next() {
-				var s models.Service
-				if err := svcRows.Scan(&s.ID, &s.Name, &s.Status, &s.OrganizationID); err == nil {
-					i.Services = append(i.Services, s)
-				}
-			}
-			svcRows.Close()
-			// Fetch updates
-			updRows, _ := db.DB.Query(`SELECT id, incident_id, message, created_at FROM incident_updates WHERE incident_id = $1 ORDER BY created_at ASC`, i.ID)
-			for updRows.Next() {
-				var u models.IncidentUpdate
-				if err := updRows.Scan(&u.ID, &u.IncidentID, &u.Message, &u.CreatedAt); err == nil {
-					i.Updates = append(i.Updates, u)
-				}
-			}
-			updRows.Close()
-			incidents = append(incidents, i)
+		writePage(c, "incidents", nil, "", err)
+		return
+	}
+	incidents, next, err := stores.Incidents.List(c.Request.Context(), q)
+	if incidents == nil {
+		incidents = []models.Incident{}
+	}
+	writePage(c, "incidents", incidents, next, err)
+}
+
+// GET /incidents/search?q=&limit= (ranked full-text search over titles, descriptions and updates)
+func searchIncidents(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required", "code": codeInvalidQuery})
+		return
+	}
+	limit := store.DefaultPageSize
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > store.MaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", store.MaxPageSize), "code": codeInvalidQuery})
+			return
 		}
+		limit = n
+	}
+
+	results, err := stores.Incidents.Search(c.Request.Context(), c.GetString("organizationId"), q, limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "incident search failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search incidents"})
+		return
 	}
-	c.JSON(http.StatusOK, incidents)
+	if results == nil {
+		results = []models.IncidentSearchResult{}
+	}
+	c.JSON(http.StatusOK, gin.H{"items": results})
+}
+
+// incidentInput is the writable part of an incident shared by the HTTP handlers and integrations.
+type incidentInput struct {
+	Title       string   `json:"title" binding:"required,max=200"`
+	Description string   `json:"description" binding:"max=10000"`
+	Type        string   `json:"type" binding:"required,oneof=incident maintenance"`
+	Status      string   `json:"status" binding:"required,incident_status"`
+	IsResolved  bool     `json:"isResolved"`
+	ServiceIDs  []string `json:"serviceIds" binding:"max=100,dive,uuid"`
+}
+
+// serviceRefs locates the input's service IDs for checkServiceRefs.
+func (in incidentInput) serviceRefs() []serviceRef {
+	refs := make([]serviceRef, len(in.ServiceIDs))
+	for i, id := range in.ServiceIDs {
+		refs[i] = serviceRef{field: fmt.Sprintf("serviceIds[%d]", i), id: id}
+	}
+	return refs
 }
 
 // POST /incidents (create incident/maintenance)
 func createIncident(c *gin.Context) {
-	var input struct {
-		Title       string   `json:"title"`
-		Description string   `json:"description"`
-		Type        string   `json:"type"`
-		Status      string   `json:"status"`
-		ServiceIDs  []string `json:"serviceIds"`
-	}
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body"})
+	var input incidentInput
+	if !bindBody(c, &input) || !checkServiceRefs(c, input.serviceRefs()...) {
 		return
 	}
-	id := uuid.NewString()
+	input.IsResolved = false
 	orgID := c.GetString("organizationId")
-	_, err := db.DB.Exec(`INSERT INTO incidents (id, title, description, type, status, is_resolved, organization_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		id, input.Title, input.Description, input.Type, input.Status, false, orgID)
+	id, err := openIncident(c.Request.Context(), orgID, input)
 	if err != nil {
-		log.Println("❌ Insert failed:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert incident"})
 		return
 	}
-	for _, sid := range input.ServiceIDs {
-		_, _ = db.DB.Exec(`INSERT INTO incident_services (incident_id, service_id) VALUES ($1, $2)`, id, sid)
-	}
-	c.JSON(http.StatusOK, gin.H{"id": id})
+	c.Header("ETag", etag(1))
+	c.JSON(http.StatusOK, gin.H{"id": id, "version": 1})
 
-	// Email notification
-	notifyTo := os.Getenv("SMTP_NOTIFY_TO")
-	if notifyTo != "" {
-		to := strings.Split(notifyTo, ",")
-		subject := "[StatusPage] New Incident: " + input.Title
-		body := "Incident '" + input.Title + "' was created. Status: " + input.Status + "\nDescription: " + input.Description
-		_ = utils.SendEmail(to, subject, body)
-	}
+	recordAudit(c, "incident.created", "incident", id, nil, auditIncident(c.Request.Context(), orgID, id))
+}
 
-	// Broadcast SSE
-	msg, _ := json.Marshal(map[string]interface{}{"event": "incident_created", "id": id})
-	BroadcastSSE(string(msg))
+// GET /incidents/:id (with services and updates; its version is the ETag)
+func getIncident(c *gin.Context) {
+	i, err := stores.Incidents.Get(c.Request.Context(), c.GetString("organizationId"), c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found or not owned by org"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident"})
+		return
+	}
+	writeVersioned(c, i.Version, i)
 }
 
-// PUT /incidents/:id (update/resolve, update services)
+// PUT /incidents/:id (update/resolve, update services; If-Match is required)
 func updateIncident(c *gin.Context) {
+	if !requireIfMatch(c) {
+		return
+	}
+	var input incidentInput
+	if !bindBody(c, &input) {
+		return
+	}
+	id := c.Param("id")
+	current, ok := currentIncident(c, id)
+	if !ok {
+		return
+	}
+	saveIncident(c, id, input, ifMatchVersion(c, current.Version))
+}
+
+// PATCH /incidents/:id (JSON Merge Patch; without If-Match it applies to the version read here)
+func patchIncident(c *gin.Context) {
 	id := c.Param("id")
+	current, ok := currentIncident(c, id)
+	if !ok {
+		return
+	}
+	version := ifMatchVersion(c, current.Version)
+	if version == 0 {
+		version = current.Version
+	}
+
+	input := incidentInput{
+		Title:       current.Title,
+		Description: current.Description,
+		Type:        current.Type,
+		Status:      current.Status,
+		IsResolved:  current.IsResolved,
+		ServiceIDs:  serviceIDsOf(current.Services),
+	}
+	if err := applyMergePatch(c, &input); err != nil {
+		writePatchError(c, err)
+		return
+	}
+	if !validateBody(c, &input) {
+		return
+	}
+	saveIncident(c, id, input, version)
+}
+
+// currentIncident loads the incident a write applies to, responding with an error when it cannot.
+func currentIncident(c *gin.Context, id string) (models.Incident, bool) {
+	i, err := stores.Incidents.Get(c.Request.Context(), c.GetString("organizationId"), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found or not owned by org"})
+		return i, false
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident"})
+		return i, false
+	}
+	return i, true
+}
+
+// saveIncident applies a validated PUT or PATCH, answering 412 when version is stale.
+func saveIncident(c *gin.Context, id string, input incidentInput, version int) {
+	if input.IsResolved && !middleware.HasPermission(c, middleware.PermIncidentsResolve) {
+		middleware.AbortForbidden(c, middleware.PermIncidentsResolve)
+		return
+	}
+	if !checkServiceRefs(c, input.serviceRefs()...) {
+		return
+	}
 	orgID := c.GetString("organizationId")
-	var input struct {
-		Title       string   `json:"title"`
-		Description string   `json:"description"`
-		Type        string   `json:"type"`
-		Status      string   `json:"status"`
-		IsResolved  bool     `json:"isResolved"`
-		ServiceIDs  []string `json:"serviceIds"`
+	ctx := c.Request.Context()
+	before := auditIncident(ctx, orgID, id)
+	version, err := reviseIncident(ctx, orgID, id, input, version)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found or not owned by org"})
+		return
 	}
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body"})
+	if err == store.ErrVersionConflict {
+		current, _ := stores.Incidents.Get(ctx, orgID, id)
+		writeConflict(c, "Incident", current.Version, current)
 		return
 	}
-	_, err := db.DB.Exec(`UPDATE incidents SET title=$1, description=$2, type=$3, status=$4, is_resolved=$5, updated_at=now() WHERE id=$6 AND organization_id=$7`,
-		input.Title, input.Description, input.Type, input.Status, input.IsResolved, id, orgID)
 	if err != nil {
-		log.Println("❌ Update failed:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident"})
 		return
 	}
-	// Update affected services
-	_, _ = db.DB.Exec(`DELETE FROM incident_services WHERE incident_id = $1`, id)
-	for _, sid := range input.ServiceIDs {
-		_, _ = db.DB.Exec(`INSERT INTO incident_services (incident_id, service_id) VALUES ($1, $2)`, id, sid)
-	}
-	c.JSON(http.StatusOK, gin.H{"id": id})
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, gin.H{"id": id, "version": version})
 
-	// Email notification
-	notifyTo := os.Getenv("SMTP_NOTIFY_TO")
-	if notifyTo != "" {
-		to := strings.Split(notifyTo, ",")
-		subject := "[StatusPage] Incident Updated: " + input.Title
-		body := "Incident '" + input.Title + "' was updated. New status: " + input.Status + "\nDescription: " + input.Description
-		_ = utils.SendEmail(to, subject, body)
+	action := "incident.updated"
+	if input.IsResolved && before != nil && !before.IsResolved {
+		action = "incident.resolved"
 	}
-
-	// Broadcast SSE
-	msg, _ := json.Marshal(map[string]interface{}{"event": "incident_updated", "id": id})
-	BroadcastSSE(string(msg))
+	recordAudit(c, action, "incident", id, before, auditIncident(ctx, orgID, id))
 }
 
 // POST /incidents/:id/update (add update message)
 func addIncidentUpdate(c *gin.Context) {
 	id := c.Param("id")
 	var input struct {
-		Message string `json:"message"`
+		Message string `json:"message" binding:"required,max=10000"`
 	}
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body"})
+	if !bindBody(c, &input) {
+		return
+	}
+	u, err := appendIncidentUpdate(c.Request.Context(), c.GetString("organizationId"), id, input.Message)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found or not owned by org"})
 		return
 	}
-	uid := uuid.NewString()
-	_, err := db.DB.Exec(`INSERT INTO incident_updates (id, incident_id, message) VALUES ($1, $2, $3)`, uid, id, input.Message)
 	if err != nil {
-		log.Println("❌ Insert update failed:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add update"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"id": uid})
+	c.JSON(http.StatusOK, gin.H{"id": u.ID})
 
-	// Broadcast SSE
-	msg, _ := json.Marshal(map[string]interface{}{"event": "incident_update_added", "id": id})
-	BroadcastSSE(string(msg))
+	recordAudit(c, "incident.update_added", "incident", id, nil, u)
 }
 
-// Public GET handler for all incidents (no auth)
-func PublicGetIncidents(c *gin.Context) {
-	rows, err := db.DB.Query("SELECT id, title, description, type, status, is_resolved, organization_id, created_at, updated_at FROM incidents ORDER BY created_at DESC")
+// DELETE /incidents/:id (archives the incident; its timeline is kept until purged)
+func deleteIncident(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	archived, err := stores.Incidents.Archive(c.Request.Context(), orgID, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found or not owned by org"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "archive failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete incident"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"archived": true, "id": id, "archivedAt": archived.ArchivedAt})
+
+	before := snapshotOf(archived)
+	before.ArchivedAt = nil
+	recordAudit(c, "incident.archived", "incident", id, before, snapshotOf(archived))
+	emitEvent(orgID, "incident_archived", id, archived, serviceIDsOf(archived.Services)...)
+}
+
+// POST /incidents/:id/restore (brings back an archived incident)
+func restoreIncident(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	restored, archivedAt, err := stores.Incidents.Restore(c.Request.Context(), orgID, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archived incident not found or not owned by org"})
+		return
+	}
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch incidents"})
-		return
-	}
-	defer rows.Close()
-
-	var incidents []models.Incident
-	for rows.Next() {
-		var i models.Incident
-		if err := rows.Scan(&i.ID, &i.Title, &i.Description, &i.Type, &i.Status, &i.IsResolved, &i.OrganizationID, &i.CreatedAt, &i.UpdatedAt); err == nil {
-			// Fetch affected services
-			svcRows, _ := db.DB.Query(`SELECT s.id, s.name, s.status, s.organization_id FROM services s JOIN incident_services isv ON s.id = isv.service_id WHERE isv.incident_id = $1`, i.ID)
-			for svcRows.Next() {
-				var s models.Service
-				if err := svcRows.Scan(&s.ID, &s.Name, &s.Status, &s.OrganizationID); err == nil {
-					i.Services = append(i.Services, s)
-				}
-			}
-			svcRows.Close()
-			// Fetch updates
-			updRows, _ := db.DB.Query(`SELECT id, incident_id, message, created_at FROM incident_updates WHERE incident_id = $1 ORDER BY created_at ASC`, i.ID)
-			for updRows.Next() {
-				var u models.IncidentUpdate
-				if err := updRows.Scan(&u.ID, &u.IncidentID, &u.Message, &u.CreatedAt); err == nil {
-					i.Updates = append(i.Updates, u)
-				}
-			}
-			updRows.Close()
-			incidents = append(incidents, i)
+		slog.ErrorContext(c.Request.Context(), "restore failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore incident"})
+		return
+	}
+	c.Header("ETag", etag(restored.Version))
+	c.JSON(http.StatusOK, restored)
+
+	before := snapshotOf(restored)
+	before.ArchivedAt = &archivedAt
+	recordAudit(c, "incident.restored", "incident", id, before, snapshotOf(restored))
+	emitEvent(orgID, "incident_restored", id, restored, serviceIDsOf(restored.Services)...)
+}
+
+// incidentSnapshot is the incident state recorded in the audit log: its fields
+// and affected service IDs, without the timeline, which is audited per update.
+type incidentSnapshot struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	IsResolved  bool       `json:"isResolved"`
+	ServiceIDs  []string   `json:"serviceIds"`
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty"`
+}
+
+// auditIncident returns nil when the incident does not belong to the org.
+func auditIncident(ctx context.Context, orgID, id string) *incidentSnapshot {
+	i, err := stores.Incidents.Get(ctx, orgID, id)
+	if err != nil {
+		return nil
+	}
+	return snapshotOf(i)
+}
+
+func snapshotOf(i models.Incident) *incidentSnapshot {
+	return &incidentSnapshot{
+		Title:       i.Title,
+		Description: i.Description,
+		Type:        i.Type,
+		Status:      i.Status,
+		IsResolved:  i.IsResolved,
+		ServiceIDs:  serviceIDsOf(i.Services),
+		ArchivedAt:  i.ArchivedAt,
+	}
+}
+
+// openIncident inserts an incident with its affected services and notifies subscribers.
+func openIncident(ctx context.Context, orgID string, input incidentInput) (string, error) {
+	id, err := stores.Incidents.Create(ctx, orgID, store.IncidentChange(input))
+	if err != nil {
+		slog.ErrorContext(ctx, "insert failed", "err", err)
+		return "", err
+	}
+	announceIncident(ctx, orgID, id, input)
+	return id, nil
+}
+
+// announceIncident notifies subscribers and pages on-call integrations about a new incident.
+func announceIncident(ctx context.Context, orgID, id string, input incidentInput) {
+	// Email notification (new incidents skip digests and quiet hours)
+	notifier.Email(orgID,
+		"[StatusPage] New Incident: "+input.Title,
+		"Incident '"+input.Title+"' was created. Status: "+input.Status+"\nDescription: "+input.Description,
+		input.Type == "incident")
+
+	notifier.NewIncidentSMS(orgID, input.Title, input.Type)
+
+	emitIncidentEvent(ctx, orgID, "incident_created", id, notify.EscalationTrigger)
+}
+
+// reviseIncident replaces an incident's fields and affected services, notifies subscribers
+// and returns the new version. It returns sql.ErrNoRows when the incident does not belong
+// to the org, or store.ErrVersionConflict when version (0 for any) is stale.
+func reviseIncident(ctx context.Context, orgID, id string, input incidentInput, version int) (int, error) {
+	prev, err := stores.Incidents.Update(ctx, orgID, id, store.IncidentChange(input), version)
+	if err != nil {
+		if err != sql.ErrNoRows && err != store.ErrVersionConflict {
+			slog.ErrorContext(ctx, "update failed", "err", err)
 		}
+		return 0, err
+	}
+
+	// Email notification
+	notifier.Email(orgID,
+		"[StatusPage] Incident Updated: "+input.Title,
+		"Incident '"+input.Title+"' was updated. New status: "+input.Status+"\nDescription: "+input.Description,
+		false)
+
+	emitIncidentEvent(ctx, orgID, "incident_updated", id, escalationFor(prev, input))
+	return prev.Version + 1, nil
+}
+
+// appendIncidentUpdate posts a message to an org-owned incident's timeline.
+func appendIncidentUpdate(ctx context.Context, orgID, id, message string) (models.IncidentUpdate, error) {
+	u, err := stores.Updates.Add(ctx, orgID, id, message)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "insert update failed", "err", err)
+		}
+		return u, err
+	}
+
+	related, err := stores.Incidents.ServiceIDs(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not load incident services", "err", err)
 	}
-	c.JSON(200, incidents)
+	emitEvent(orgID, "incident_update_added", id, u, related...)
+	return u, nil
+}
+
+func isValidIncidentStatus(status string) bool {
+	switch status {
+	case "Investigating", "Identified", "Monitoring", "Resolved", "Scheduled", "In Progress", "Completed":
+		return true
+	}
+	return false
+}
+
+// escalationFor picks the page an update sends to on-call integrations: resolving
+// resolves, reopening triggers again, and moving an open incident on from
+// Investigating acknowledges. Other edits send nothing ("").
+func escalationFor(prev models.Incident, input incidentInput) string {
+	switch {
+	case !prev.IsResolved && input.IsResolved:
+		return notify.EscalationResolve
+	case prev.IsResolved && !input.IsResolved:
+		return notify.EscalationTrigger
+	case !input.IsResolved && prev.Status == "Investigating" && input.Status != prev.Status:
+		return notify.EscalationAcknowledge
+	}
+	return ""
+}
+
+// emitIncidentEvent reloads the incident so subscribers receive its current state,
+// and sends escalation to on-call integrations unless it is "".
+func emitIncidentEvent(ctx context.Context, orgID, event, id, escalation string) {
+	incident, err := stores.Incidents.Get(ctx, orgID, id)
+	if err != nil {
+		slog.Error("could not load incident for event", "err", err)
+		emitEvent(orgID, event, id, gin.H{"id": id})
+		return
+	}
+	emitEvent(orgID, event, id, incident, serviceIDsOf(incident.Services)...)
+
+	if escalation != "" {
+		notifier.Escalate(orgID, escalation, incident)
+	}
+}
+
+// Public GET handler for all incidents (no auth, same parameters as GET /incidents)
+func PublicGetIncidents(c *gin.Context) {
+	listIncidents(c, "")
 }
@@ -0,0 +1,296 @@
+package routes
+
+import (
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/notify"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCreateIncident(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	svc := a.service("API", "Major Outage")
+
+	w := a.do("POST", "/api/incidents", `{"title":"API down","type":"incident","status":"Investigating","serviceIds":["`+svc.ID+`"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.db.Links[created.ID]; !reflect.DeepEqual(got, []string{svc.ID}) {
+		t.Errorf("linked services = %v", got)
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"incident.created"}) {
+		t.Errorf("audit actions = %v", got)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"incident_created"}) {
+		t.Errorf("events = %v", got)
+	}
+	if got := a.notifier.events[0].Related; !reflect.DeepEqual(got, []string{svc.ID}) {
+		t.Errorf("event related = %v", got)
+	}
+	if got := a.notifier.escalations; !reflect.DeepEqual(got, []string{notify.EscalationTrigger}) {
+		t.Errorf("escalations = %v", got)
+	}
+	if len(a.notifier.emails) != 1 || len(a.notifier.sms) != 1 {
+		t.Errorf("expected one email and one SMS, got %v and %v", a.notifier.emails, a.notifier.sms)
+	}
+}
+
+func TestUpdateIncident(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	svc := a.service("API", "Major Outage")
+	id := a.incident("API down", svc.ID)
+
+	w := a.do("PUT", "/api/incidents/"+id, `{"title":"API down","type":"incident","status":"Identified","serviceIds":["`+svc.ID+`"]}`, "If-Match", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Incidents[id]; got.Status != "Identified" || got.IsResolved {
+		t.Errorf("stored incident = %+v", got)
+	}
+
+	w = a.do("PUT", "/api/incidents/"+id, `{"title":"API down","type":"incident","status":"Resolved","isResolved":true,"serviceIds":["`+svc.ID+`"]}`, "If-Match", `"2"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("resolve: status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"incident.updated", "incident.resolved"}) {
+		t.Errorf("audit actions = %v", got)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"incident_updated", "incident_updated"}) {
+		t.Errorf("events = %v", got)
+	}
+	if got := a.notifier.escalations; !reflect.DeepEqual(got, []string{notify.EscalationAcknowledge, notify.EscalationResolve}) {
+		t.Errorf("escalations = %v", got)
+	}
+}
+
+func TestUpdateIncidentNotFound(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	id := a.incident("API down")
+	a.db.Incidents[id].OrganizationID = "org_other"
+
+	for _, target := range []string{"00000000-0000-0000-0000-000000000000", id} {
+		w := a.do("PUT", "/api/incidents/"+target, `{"title":"API down","type":"incident","status":"Identified"}`, "If-Match", `*`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("PUT %s: status %d: %s", target, w.Code, w.Body)
+		}
+	}
+	if len(a.db.Audit) != 0 || len(a.notifier.events) != 0 || len(a.notifier.escalations) != 0 {
+		t.Fatal("a failed update must not be audited, announced or escalated")
+	}
+}
+
+func TestAddIncidentUpdate(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	svc := a.service("API", "Major Outage")
+	id := a.incident("API down", svc.ID)
+
+	if w := a.do("POST", "/api/incidents/"+id+"/update", `{"message":"Rolling back"}`); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if len(a.db.Updates) != 1 || a.db.Updates[0].Message != "Rolling back" {
+		t.Fatalf("updates = %+v", a.db.Updates)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"incident_update_added"}) {
+		t.Errorf("events = %v", got)
+	}
+	if got := a.notifier.events[0].Related; !reflect.DeepEqual(got, []string{svc.ID}) {
+		t.Errorf("event related = %v", got)
+	}
+	if w := a.do("POST", "/api/incidents/00000000-0000-0000-0000-000000000000/update", `{"message":"x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown incident: status %d", w.Code)
+	}
+}
+
+func TestUpdateIncidentIfMatch(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	id := a.incident("API down")
+
+	w := a.do("PUT", "/api/incidents/"+id, `{"title":"API down","type":"incident","status":"Identified"}`, "If-Match", `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("status %d with ETag %s: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	w = a.do("PUT", "/api/incidents/"+id, `{"title":"API down","type":"incident","status":"Monitoring"}`, "If-Match", `"1"`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Incidents[id]; got.Status != "Identified" || got.Version != 2 {
+		t.Errorf("stored incident = %+v", got)
+	}
+	if got := a.notifier.escalations; !reflect.DeepEqual(got, []string{notify.EscalationAcknowledge}) {
+		t.Errorf("a conflicting update paged: escalations = %v", got)
+	}
+}
+
+func TestPatchIncident(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	svc := a.service("API", "Major Outage")
+	id := a.incident("API down", svc.ID)
+
+	w := a.do("PATCH", "/api/incidents/"+id, `{"isResolved":true,"status":"Resolved"}`, "Content-Type", "application/merge-patch+json", "If-Match", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	got := a.db.Incidents[id]
+	if !got.IsResolved || got.Title != "API down" || !reflect.DeepEqual(a.db.Links[id], []string{svc.ID}) {
+		t.Errorf("patch lost fields: %+v, services %v", got, a.db.Links[id])
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"incident.resolved"}) {
+		t.Errorf("audit actions = %v", got)
+	}
+}
+
+func TestDeleteAndRestoreIncident(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleAdmin)
+	svc := a.service("API", "Major Outage")
+	id := a.incident("API down", svc.ID)
+
+	if w := a.do("DELETE", "/api/incidents/"+id, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d: %s", w.Code, w.Body)
+	}
+	if w := a.do("GET", "/api/incidents/"+id, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("archived incident: status %d", w.Code)
+	}
+	if w := a.do("POST", "/api/incidents/"+id+"/update", `{"message":"x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("update on an archived incident: status %d", w.Code)
+	}
+	if w := a.do("POST", "/api/incidents/"+id+"/restore", ""); w.Code != http.StatusOK {
+		t.Fatalf("restore: status %d: %s", w.Code, w.Body)
+	}
+	if w := a.do("GET", "/api/incidents/"+id, ""); w.Code != http.StatusOK {
+		t.Fatalf("restored incident: status %d", w.Code)
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"incident.archived", "incident.restored"}) {
+		t.Fatalf("audit actions = %v", got)
+	}
+	archived, restored := a.auditChanges(t, 0), a.auditChanges(t, 1)
+	if len(archived) != 1 || archived["archivedAt"].Before != nil || archived["archivedAt"].After == nil {
+		t.Errorf("archive diff = %v, want archivedAt set", archived)
+	}
+	if len(restored) != 1 || restored["archivedAt"].Before != archived["archivedAt"].After || restored["archivedAt"].After != nil {
+		t.Errorf("restore diff = %v, want archivedAt cleared", restored)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"incident_archived", "incident_restored"}) {
+		t.Errorf("events = %v", got)
+	}
+	for _, e := range a.notifier.events {
+		if !reflect.DeepEqual(e.Related, []string{svc.ID}) {
+			t.Errorf("%s related = %v", e.Type, e.Related)
+		}
+	}
+}
+
+func TestCreateIncidentRejectsUnknownServices(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleAdmin)
+	svc := a.service("API", "Major Outage")
+	archived := a.service("Old API", "Operational")
+	other := a.service("Their API", "Operational")
+	a.db.Services[other.ID].OrganizationID = "org_other"
+	if w := a.do("DELETE", "/api/services/"+archived.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("archive: status %d: %s", w.Code, w.Body)
+	}
+
+	w := a.do("POST", "/api/incidents", `{"title":"API down","type":"incident","status":"Investigating",
+		"serviceIds":["`+svc.ID+`","`+other.ID+`","`+archived.ID+`"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Fields []fieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, f := range body.Fields {
+		if f.Code != codeUnknownService {
+			t.Errorf("%s: code %s", f.Field, f.Code)
+		}
+		fields = append(fields, f.Field)
+	}
+	if !reflect.DeepEqual(fields, []string{"serviceIds[1]", "serviceIds[2]"}) {
+		t.Errorf("rejected fields %v, want serviceIds[1] and serviceIds[2]", fields)
+	}
+	if len(a.db.Incidents) != 0 {
+		t.Fatal("the incident must not be created")
+	}
+}
+
+func TestEscalationFor(t *testing.T) {
+	open := models.Incident{Status: "Investigating"}
+	identified := models.Incident{Status: "Identified"}
+	resolved := models.Incident{Status: "Resolved", IsResolved: true}
+
+	cases := []struct {
+		name  string
+		prev  models.Incident
+		input incidentInput
+		want  string
+	}{
+		{"resolve", identified, incidentInput{Status: "Resolved", IsResolved: true}, notify.EscalationResolve},
+		{"reopen", resolved, incidentInput{Status: "Investigating"}, notify.EscalationTrigger},
+		{"acknowledge", open, incidentInput{Status: "Identified"}, notify.EscalationAcknowledge},
+		{"title edit", open, incidentInput{Title: "renamed", Status: "Investigating"}, ""},
+		{"later status change", identified, incidentInput{Status: "Monitoring"}, ""},
+		{"edit while resolved", resolved, incidentInput{Description: "postmortem", Status: "Resolved", IsResolved: true}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := escalationFor(tc.prev, tc.input); got != tc.want {
+				t.Fatalf("escalationFor = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateIncidentRequiresIfMatch(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	id := a.incident("API down")
+
+	w := a.do("PUT", "/api/incidents/"+id, `{"title":"API down","type":"incident","status":"Identified"}`)
+	if w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Incidents[id]; got.Status != "Investigating" || got.Version != 1 {
+		t.Errorf("stored incident = %+v", got)
+	}
+	if len(a.db.Audit) != 0 || len(a.notifier.events) != 0 {
+		t.Fatal("a rejected update must not be audited or announced")
+	}
+}
+
+func TestPatchIncidentKeepsArchivedServices(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleAdmin)
+	svc := a.service("API", "Major Outage")
+	id := a.incident("API down", svc.ID)
+
+	if w := a.do("DELETE", "/api/services/"+svc.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("archive: status %d: %s", w.Code, w.Body)
+	}
+	if w := a.do("PATCH", "/api/incidents/"+id, `{"status":"Identified"}`, "Content-Type", "application/merge-patch+json"); w.Code != http.StatusOK {
+		t.Fatalf("patch: status %d: %s", w.Code, w.Body)
+	}
+	if w := a.do("POST", "/api/services/"+svc.ID+"/restore", ""); w.Code != http.StatusOK {
+		t.Fatalf("restore: status %d: %s", w.Code, w.Body)
+	}
+
+	w := a.do("GET", "/api/incidents/"+id, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var got models.Incident
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Services) != 1 || got.Services[0].ID != svc.ID {
+		t.Errorf("services = %+v, want the restored service", got.Services)
+	}
+}
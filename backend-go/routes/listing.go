@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"backend-go/store"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams reads the paging parameters shared by the listings: ?limit, ?cursor and ?sort.
+func pageParams(c *gin.Context) (limit int, cursor, sort string, err error) {
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return 0, "", "", &store.QueryError{Msg: "limit must be an integer"}
+		}
+	}
+	return limit, c.Query("cursor"), c.Query("sort"), nil
+}
+
+// archivedParam reads ?archived. Archived rows are never listed publicly, so it
+// is ignored when orgID is empty.
+func archivedParam(c *gin.Context, orgID string) (bool, error) {
+	v := c.Query("archived")
+	if v == "" || orgID == "" {
+		return false, nil
+	}
+	archived, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &store.QueryError{Msg: "archived must be true or false"}
+	}
+	return archived, nil
+}
+
+// serviceQuery reads ?status, ?q, ?archived and the paging parameters.
+func serviceQuery(c *gin.Context, orgID string) (store.ServiceQuery, error) {
+	q := store.ServiceQuery{OrgID: orgID, Status: c.Query("status"), Search: c.Query("q")}
+	var err error
+	if q.Archived, err = archivedParam(c, orgID); err != nil {
+		return q, err
+	}
+	q.Limit, q.Cursor, q.Sort, err = pageParams(c)
+	return q, err
+}
+
+// incidentQuery reads ?type, ?status, ?resolved, ?serviceId, ?createdAfter,
+// ?createdBefore, ?q, ?archived and the paging parameters.
+func incidentQuery(c *gin.Context, orgID string) (store.IncidentQuery, error) {
+	q := store.IncidentQuery{
+		OrgID:     orgID,
+		Type:      c.Query("type"),
+		Status:    c.Query("status"),
+		ServiceID: c.Query("serviceId"),
+		Search:    c.Query("q"),
+	}
+	if v := c.Query("resolved"); v != "" {
+		resolved, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, &store.QueryError{Msg: "resolved must be true or false"}
+		}
+		q.Resolved = &resolved
+	}
+	for param, dst := range map[string]**time.Time{"createdAfter": &q.CreatedAfter, "createdBefore": &q.CreatedBefore} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, &store.QueryError{Msg: param + " must be an RFC 3339 timestamp"}
+			}
+			*dst = &t
+		}
+	}
+	var err error
+	if q.Archived, err = archivedParam(c, orgID); err != nil {
+		return q, err
+	}
+	q.Limit, q.Cursor, q.Sort, err = pageParams(c)
+	return q, err
+}
+
+// auditQuery reads ?actor, ?action, ?targetType, ?targetId, ?since, ?until,
+// ?limit and ?cursor.
+func auditQuery(c *gin.Context, orgID string) (store.AuditQuery, error) {
+	q := store.AuditQuery{
+		OrgID:      orgID,
+		ActorID:    c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("targetId"),
+	}
+	for param, dst := range map[string]**time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, &store.QueryError{Msg: param + " must be an RFC 3339 timestamp"}
+			}
+			*dst = &t
+		}
+	}
+	var err error
+	q.Limit, q.Cursor, _, err = pageParams(c)
+	return q, err
+}
+
+// writePage responds with {"items": [...], "next_cursor": "..."}; next_cursor is
+// null on the last page. items must not be a nil slice. Query errors are reported as 400.
+func writePage(c *gin.Context, what string, items interface{}, next string, err error) {
+	var qerr *store.QueryError
+	if errors.As(err, &qerr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": qerr.Msg, "code": codeInvalidQuery})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch " + what})
+		return
+	}
+	var nextCursor *string
+	if next != "" {
+		nextCursor = &next
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "next_cursor": nextCursor})
+}
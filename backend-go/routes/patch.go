@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errPatchMediaType = errors.New("Content-Type must be application/merge-patch+json")
+
+// applyMergePatch applies the request body, an RFC 7396 JSON Merge Patch, to
+// input, which holds the resource's current writable fields. Members the patch
+// sets to null are reset to their zero value; unknown members are rejected.
+func applyMergePatch(c *gin.Context, input interface{}) error {
+	if mt, _, _ := mime.ParseMediaType(c.ContentType()); mt != "application/merge-patch+json" && mt != "application/json" {
+		return errPatchMediaType
+	}
+	raw, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+	var patch interface{}
+	if err := json.Unmarshal(raw, &patch); err != nil {
+		return err
+	}
+	doc, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(input).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	return dec.Decode(input)
+}
+
+// mergePatch implements the MergePatch algorithm of RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// writePatchError reports a patch that could not be applied.
+func writePatchError(c *gin.Context, err error) {
+	if err == errPatchMediaType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error(), "code": "unsupported_media_type"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merge patch: " + err.Error(), "code": codeInvalidBody})
+}
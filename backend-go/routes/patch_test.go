@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cases from RFC 7396, Appendix A.
+func TestMergePatch(t *testing.T) {
+	cases := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		var target, patch, want interface{}
+		for _, v := range []struct {
+			raw string
+			dst *interface{}
+		}{{tc.target, &target}, {tc.patch, &patch}, {tc.want, &want}} {
+			if err := json.Unmarshal([]byte(v.raw), v.dst); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+			t.Errorf("mergePatch(%s, %s) = %v, want %s", tc.target, tc.patch, got, tc.want)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	patchRequest := func(contentType, body string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", contentType)
+		return c
+	}
+
+	input := incidentInput{Title: "API down", Description: "Errors", Type: "incident", Status: "Investigating", ServiceIDs: []string{"a"}}
+	err := applyMergePatch(patchRequest("application/merge-patch+json", `{"status":"Identified","description":null}`), &input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := incidentInput{Title: "API down", Type: "incident", Status: "Identified", ServiceIDs: []string{"a"}}
+	if !reflect.DeepEqual(input, want) {
+		t.Errorf("patched = %+v, want %+v", input, want)
+	}
+
+	if err := applyMergePatch(patchRequest("text/plain", `{}`), &input); err != errPatchMediaType {
+		t.Errorf("text/plain: err = %v", err)
+	}
+	if err := applyMergePatch(patchRequest("application/json", `{"severity":"high"}`), &input); err == nil {
+		t.Error("unknown members must be rejected")
+	}
+	if err := applyMergePatch(patchRequest("application/json", `{"title":`), &input); err == nil {
+		t.Error("malformed JSON must be rejected")
+	}
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoleRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/roles", getRoles)
+	g.PUT("/roles/:userId", setRole)
+	g.DELETE("/roles/:userId", deleteRole)
+}
+
+// GET /roles (local overrides only; other members get their role from the JWT)
+func getRoles(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT organization_id, user_id, role, created_at FROM org_roles WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+	defer rows.Close()
+
+	roles := []models.OrgRole{}
+	for rows.Next() {
+		var r models.OrgRole
+		if err := rows.Scan(&r.OrganizationID, &r.UserID, &r.Role, &r.CreatedAt); err == nil {
+			roles = append(roles, r)
+		}
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// PUT /roles/:userId
+func setRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required,role"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+
+	r := models.OrgRole{OrganizationID: c.GetString("organizationId"), UserID: c.Param("userId"), Role: input.Role}
+	err := db.DB.QueryRow(`INSERT INTO org_roles (organization_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role RETURNING created_at`,
+		r.OrganizationID, r.UserID, r.Role).Scan(&r.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "upsert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set role"})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// DELETE /roles/:userId (the user falls back to their identity provider role)
+func deleteRole(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	userID := c.Param("userId")
+	res, err := db.DB.Exec(`DELETE FROM org_roles WHERE organization_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role override not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "userId": userID})
+}
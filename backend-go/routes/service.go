@@ -1,179 +1,259 @@
 package routes
 
 import (
-	"backend-go/db"
+	"backend-go/middleware"
 	"backend-go/models"
-	"log"
+	"backend-go/store"
+	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"encoding/json"
-	"backend-go/utils"
-	"os"
-	"strings"
+	"database/sql"
 	"time"
 )
 
 func RegisterServiceRoutes(rg *gin.RouterGroup) {
-	rg.GET("/services", getServices)
-	rg.POST("/services", createService)
-	rg.PUT("/services/:id", updateService)
-	rg.DELETE("/services/:id", deleteService)
+	rg.GET("/services", middleware.RequirePermission(middleware.PermServicesRead), getServices)
+	rg.POST("/services", middleware.RequirePermission(middleware.PermServicesWrite), createService)
+	rg.GET("/services/:id", middleware.RequirePermission(middleware.PermServicesRead), getService)
+	rg.PUT("/services/:id", middleware.RequirePermission(middleware.PermServicesWrite), updateService)
+	rg.PATCH("/services/:id", middleware.RequirePermission(middleware.PermServicesWrite), patchService)
+	rg.DELETE("/services/:id", middleware.RequirePermission(middleware.PermServicesDelete), deleteService)
+	rg.POST("/services/:id/restore", middleware.RequirePermission(middleware.PermServicesDelete), restoreService)
 	// rg.GET("/services/:id/uptime", GetServiceUptime)
 }
 
+// GET /services (paginated, see serviceQuery)
 func getServices(c *gin.Context) {
-	orgID := c.GetString("organizationId")
-	log.Println("📥 Fetching services for org:", orgID)
+	listServices(c, c.GetString("organizationId"))
+}
 
-	rows, err := db.DB.Query("SELECT id, name, status, organization_id FROM services WHERE organization_id = $1", orgID)
+// listServices serves a page of services; an empty orgID lists every organization.
+func listServices(c *gin.Context, orgID string) {
+	q, err := serviceQuery(c, orgID)
 	if err != nil {
-		log.Println("❌ DB error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch services"})
+		writePage(c, "services", nil, "", err)
 		return
 	}
-	defer rows.Close()
-
-	var services []models.Service
-	for rows.Next() {
-		var s models.Service
-		if err := rows.Scan(&s.ID, &s.Name, &s.Status, &s.OrganizationID); err == nil {
-			services = append(services, s)
-		}
+	services, next, err := stores.Services.List(c.Request.Context(), q)
+	if services == nil {
+		services = []models.Service{}
 	}
-
-	log.Printf("✅ %d services fetched for org %s\n", len(services), orgID)
-	c.JSON(http.StatusOK, services)
+	writePage(c, "services", services, next, err)
 }
 
 func createService(c *gin.Context) {
-	var input models.Service
-	if err := c.BindJSON(&input); err != nil {
-		log.Println("❌ Invalid body")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body"})
+	var body serviceInput
+	if !bindBody(c, &body) {
 		return
 	}
 
-	input.ID = uuid.NewString()
-	input.OrganizationID = c.GetString("organizationId")
-
-	log.Println("📦 Creating service:", input.Name, "for org:", input.OrganizationID)
-
-	_, err := db.DB.Exec("INSERT INTO services (id, name, status, organization_id) VALUES ($1, $2, $3, $4)",
-		input.ID, input.Name, input.Status, input.OrganizationID)
+	input := models.Service{
+		ID:             uuid.NewString(),
+		Name:           body.Name,
+		Status:         body.Status,
+		OrganizationID: c.GetString("organizationId"),
+		Version:        1,
+	}
 
-	if err != nil {
-		log.Println("❌ Insert failed:", err)
+	// inserts the service and its first status history entry
+	if err := stores.Services.Create(c.Request.Context(), input); err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert service"})
 		return
 	}
 
-	// Log status history
-	_, _ = db.DB.Exec("INSERT INTO service_status_history (id, service_id, status) VALUES ($1, $2, $3)", uuid.NewString(), input.ID, input.Status)
+	slog.InfoContext(c.Request.Context(), "service created", "service_id", input.ID, "org_id", input.OrganizationID)
+	c.Header("ETag", etag(input.Version))
+	c.JSON(http.StatusOK, input)
+
+	recordAudit(c, "service.created", "service", input.ID, nil, input)
 
 	// Email notification
-	notifyTo := os.Getenv("SMTP_NOTIFY_TO")
-	if notifyTo != "" {
-		to := strings.Split(notifyTo, ",")
-		subject := "[StatusPage] New Service Created: " + input.Name
-		body := "Service '" + input.Name + "' was created with status: " + input.Status
-		_ = utils.SendEmail(to, subject, body)
-	}
+	notifier.Email(input.OrganizationID,
+		"[StatusPage] New Service Created: "+input.Name,
+		"Service '"+input.Name+"' was created with status: "+input.Status,
+		false)
 
-	log.Println("✅ Service created for org:", input.OrganizationID)
-	c.JSON(http.StatusOK, input)
+	notifier.ServiceStatusSMS(input.OrganizationID, input.Name, "", input.Status)
+
+	emitEvent(input.OrganizationID, "service_created", input.ID, input)
+}
+
+// GET /services/:id (with its version as the ETag)
+func getService(c *gin.Context) {
+	s, err := stores.Services.Get(c.Request.Context(), c.GetString("organizationId"), c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or not owned by org"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service"})
+		return
+	}
+	writeVersioned(c, s.Version, s)
+}
 
-	// Broadcast SSE
-	msg, _ := json.Marshal(map[string]interface{}{"event": "service_created", "id": input.ID})
-	BroadcastSSE(string(msg))
+// serviceInput is the writable part of a service.
+type serviceInput struct {
+	Name   string `json:"name" binding:"required,max=100"`
+	Status string `json:"status" binding:"required,service_status"`
 }
 
+// PUT /services/:id (replace name and status; If-Match is required)
 func updateService(c *gin.Context) {
-	orgID := c.GetString("organizationId")
+	if !requireIfMatch(c) {
+		return
+	}
+	var input serviceInput
+	if !bindBody(c, &input) {
+		return
+	}
 	id := c.Param("id")
-
-	var input struct {
-		Name   string `json:"name"`
-		Status string `json:"status"`
+	current, ok := currentService(c, id)
+	if !ok {
+		return
 	}
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body"})
+	saveService(c, id, input, ifMatchVersion(c, current.Version))
+}
+
+// PATCH /services/:id (JSON Merge Patch; without If-Match it applies to the version read here)
+func patchService(c *gin.Context) {
+	id := c.Param("id")
+	current, ok := currentService(c, id)
+	if !ok {
 		return
 	}
-	if input.Name == "" || input.Status == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name and status required"})
+	version := ifMatchVersion(c, current.Version)
+	if version == 0 {
+		version = current.Version
+	}
+
+	input := serviceInput{Name: current.Name, Status: current.Status}
+	if err := applyMergePatch(c, &input); err != nil {
+		writePatchError(c, err)
 		return
 	}
-	if !isValidStatus(input.Status) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+	if !validateBody(c, &input) {
 		return
 	}
+	saveService(c, id, input, version)
+}
 
-	// Get previous status
-	var prevStatus string
-	err := db.DB.QueryRow("SELECT status FROM services WHERE id=$1 AND organization_id=$2", id, orgID).Scan(&prevStatus)
+// currentService loads the service a write applies to, responding with an error when it cannot.
+func currentService(c *gin.Context, id string) (models.Service, bool) {
+	s, err := stores.Services.Get(c.Request.Context(), c.GetString("organizationId"), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or not owned by org"})
+		return s, false
+	}
 	if err != nil {
-		log.Println("❌ Could not fetch previous status:", err)
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service"})
+		return s, false
 	}
+	return s, true
+}
 
-	res, err := db.DB.Exec(
-		`UPDATE services SET name=$1, status=$2 WHERE id=$3 AND organization_id=$4`,
-		input.Name, input.Status, id, orgID,
-	)
-	if err != nil {
-		log.Println("❌ Update failed:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+// saveService applies a validated PUT or PATCH, answering 412 when version is stale.
+func saveService(c *gin.Context, id string, input serviceInput, version int) {
+	orgID := c.GetString("organizationId")
+	ctx := c.Request.Context()
+	before, updated, err := reviseService(ctx, orgID, id, input.Name, input.Status, version)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or not owned by org"})
 		return
 	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or not owned by org"})
+	if err == store.ErrVersionConflict {
+		current, _ := stores.Services.Get(ctx, orgID, id)
+		writeConflict(c, "Service", current.Version, current)
 		return
 	}
-
-	// Log status history only if status changed
-	if prevStatus != input.Status {
-		_, _ = db.DB.Exec("INSERT INTO service_status_history (id, service_id, status) VALUES ($1, $2, $3)", uuid.NewString(), id, input.Status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+		return
 	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, updated)
 
-	c.JSON(http.StatusOK, gin.H{"id": id, "name": input.Name, "status": input.Status, "organizationId": orgID})
+	recordAudit(c, "service.updated", "service", id, before, updated)
+}
 
-	// Email notification
-	notifyTo := os.Getenv("SMTP_NOTIFY_TO")
-	if notifyTo != "" {
-		to := strings.Split(notifyTo, ",")
-		subject := "[StatusPage] Service Updated: " + input.Name
-		body := "Service '" + input.Name + "' was updated. New status: " + input.Status
-		_ = utils.SendEmail(to, subject, body)
+// reviseService renames a service and sets its status, recording history and notifying
+// subscribers. It returns the service before and after the change, sql.ErrNoRows when
+// the service does not belong to the org, or store.ErrVersionConflict when version
+// (0 for any) is stale.
+func reviseService(ctx context.Context, orgID, id, name, status string, version int) (models.Service, models.Service, error) {
+	updated := models.Service{ID: id, Name: name, Status: status, OrganizationID: orgID}
+
+	prev, err := stores.Services.Update(ctx, orgID, id, name, status, version)
+	if err != nil {
+		if err != sql.ErrNoRows && err != store.ErrVersionConflict {
+			slog.ErrorContext(ctx, "update failed", "err", err)
+		}
+		return prev, updated, err
 	}
+	updated.Version = prev.Version + 1
+	prevStatus := prev.Status
+
+	// Email notification (entering a major outage skips digests and quiet hours)
+	notifier.Email(orgID,
+		"[StatusPage] Service Updated: "+name,
+		"Service '"+name+"' was updated. New status: "+status,
+		status == "Major Outage" && prevStatus != status)
 
-	// Broadcast SSE
-	msg, _ := json.Marshal(map[string]interface{}{"event": "service_updated", "id": id})
-	BroadcastSSE(string(msg))
+	notifier.ServiceStatusSMS(orgID, name, prevStatus, status)
+
+	emitEvent(orgID, "service_updated", id, gin.H{"service": updated, "previousStatus": prevStatus})
+	return prev, updated, nil
 }
 
+// DELETE /services/:id (archives the service; its history is kept until purged)
 func deleteService(c *gin.Context) {
 	orgID := c.GetString("organizationId")
 	id := c.Param("id")
-	res, err := db.DB.Exec(
-		`DELETE FROM services WHERE id=$1 AND organization_id=$2`,
-		id, orgID,
-	)
+	archived, err := stores.Services.Archive(c.Request.Context(), orgID, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or not owned by org"})
+		return
+	}
 	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "archive failed", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete service"})
 		return
 	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or not owned by org"})
+	c.JSON(http.StatusOK, gin.H{"archived": true, "id": id, "archivedAt": archived.ArchivedAt})
+
+	before := archived
+	before.ArchivedAt, before.Version = nil, archived.Version-1
+	recordAudit(c, "service.archived", "service", id, before, archived)
+	emitEvent(orgID, "service_archived", id, archived)
+}
+
+// POST /services/:id/restore (brings back an archived service)
+func restoreService(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	restored, archivedAt, err := stores.Services.Restore(c.Request.Context(), orgID, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archived service not found or not owned by org"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "restore failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore service"})
+		return
+	}
+	c.Header("ETag", etag(restored.Version))
+	c.JSON(http.StatusOK, restored)
 
-	// Broadcast SSE
-	msg, _ := json.Marshal(map[string]interface{}{"event": "service_deleted", "id": id})
-	BroadcastSSE(string(msg))
+	before := restored
+	before.ArchivedAt, before.Version = &archivedAt, restored.Version-1
+	recordAudit(c, "service.restored", "service", id, before, restored)
+	emitEvent(orgID, "service_restored", id, restored)
 }
 
 func isValidStatus(status string) bool {
@@ -184,23 +264,9 @@ func isValidStatus(status string) bool {
 	return false
 }
 
-// Public GET handler for all services (no auth)
+// Public GET handler for all services (no auth, same parameters as GET /services)
 func PublicGetServices(c *gin.Context) {
-	rows, err := db.DB.Query("SELECT id, name, status, organization_id FROM services")
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch services"})
-		return
-	}
-	defer rows.Close()
-
-	var services []models.Service
-	for rows.Next() {
-		var s models.Service
-		if err := rows.Scan(&s.ID, &s.Name, &s.Status, &s.OrganizationID); err == nil {
-			services = append(services, s)
-		}
-	}
-	c.JSON(200, services)
+	listServices(c, "")
 }
 
 // getServiceUptime returns uptime percentage for a service over a period (default 7d)
@@ -208,37 +274,31 @@ func GetServiceUptime(c *gin.Context) {
 	serviceID := c.Param("id")
 	period := c.DefaultQuery("period", "7d")
 
-	var duration string
+	var duration time.Duration
 	switch period {
 	case "1d":
-		duration = "1 day"
+		duration = 24 * time.Hour
 	case "30d":
-		duration = "30 days"
+		duration = 30 * 24 * time.Hour
 	default:
-		duration = "7 days"
+		duration = 7 * 24 * time.Hour
 	}
 
 	// Get all status changes for the service in the period, ordered by changed_at
-	rows, err := db.DB.Query(`SELECT status, changed_at FROM service_status_history WHERE service_id = $1 AND changed_at >= now() - INTERVAL '`+duration+`' ORDER BY changed_at ASC`, serviceID)
+	changes, err := stores.History.Since(c.Request.Context(), serviceID, time.Now().Add(-duration))
 	if err != nil {
-		log.Println("❌ DB error in GetServiceUptime:", err)
+		slog.ErrorContext(c.Request.Context(), "DB error in GetServiceUptime", "err", err)
 		c.JSON(500, gin.H{"error": "Failed to fetch status history", "details": err.Error()})
 		return
 	}
-	defer rows.Close()
 
 	type statusPoint struct {
 		Status    string
 		ChangedAt string
 	}
 	var history []statusPoint
-	for rows.Next() {
-		var s statusPoint
-		var t time.Time
-		if err := rows.Scan(&s.Status, &t); err == nil {
-			s.ChangedAt = t.Format(time.RFC3339)
-			history = append(history, s)
-		}
+	for _, ch := range changes {
+		history = append(history, statusPoint{Status: ch.Status, ChangedAt: ch.ChangedAt.Format(time.RFC3339)})
 	}
 
 	// If no history, return 100% uptime (assume always up)
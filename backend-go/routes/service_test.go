@@ -0,0 +1,275 @@
+package routes
+
+import (
+	"backend-go/middleware"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCreateService(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+
+	w := a.do("POST", "/api/services", `{"name":"API","status":"Major Outage"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		ID             string `json:"id"`
+		OrganizationID string `json:"organizationId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.db.Services[created.ID]; got == nil || got.Name != "API" || got.OrganizationID != testOrg {
+		t.Fatalf("stored service = %+v", got)
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"service.created"}) {
+		t.Errorf("audit actions = %v", got)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"service_created"}) {
+		t.Errorf("events = %v", got)
+	}
+	if len(a.notifier.emails) != 1 || len(a.notifier.sms) != 1 {
+		t.Errorf("expected one email and one outage SMS, got %v and %v", a.notifier.emails, a.notifier.sms)
+	}
+}
+
+func TestCreateServiceValidation(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+
+	w := a.do("POST", "/api/services", `{"status":"Sideways"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Code   string       `json:"code"`
+		Fields []fieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != codeValidationFailed || len(body.Fields) != 2 {
+		t.Fatalf("unexpected error envelope %s", w.Body)
+	}
+	if len(a.db.Services) != 0 || len(a.notifier.events) != 0 {
+		t.Fatal("an invalid service must not be stored or announced")
+	}
+}
+
+func TestCreateServiceForbiddenForViewers(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleViewer)
+
+	if w := a.do("POST", "/api/services", `{"name":"API","status":"Operational"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if len(a.db.Services) != 0 {
+		t.Fatal("a forbidden request created a service")
+	}
+}
+
+func TestUpdateService(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+
+	w := a.do("PUT", "/api/services/"+s.ID, `{"name":"Public API","status":"Major Outage"}`, "If-Match", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Services[s.ID]; got.Name != "Public API" || got.Status != "Major Outage" {
+		t.Errorf("stored service = %+v", got)
+	}
+	if len(a.db.Audit) != 1 || a.db.Audit[0].Action != "service.updated" {
+		t.Fatalf("audit = %+v", a.db.Audit)
+	}
+	var changes map[string]fieldChange
+	if err := json.Unmarshal(a.db.Audit[0].Changes, &changes); err != nil {
+		t.Fatal(err)
+	}
+	if changes["status"] != (fieldChange{Before: "Operational", After: "Major Outage"}) ||
+		changes["version"] != (fieldChange{Before: float64(1), After: float64(2)}) || len(changes) != 3 {
+		t.Errorf("audited changes = %v", changes)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"service_updated"}) {
+		t.Errorf("events = %v", got)
+	}
+	if !reflect.DeepEqual(a.notifier.sms, []string{"Public API"}) {
+		t.Errorf("outage SMS = %v", a.notifier.sms)
+	}
+}
+
+func TestUpdateServiceRejectsInvalidStatus(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+
+	if w := a.do("PUT", "/api/services/"+s.ID, `{"name":"API","status":"Sideways"}`, "If-Match", `"1"`); w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if a.db.Services[s.ID].Status != "Operational" || len(a.notifier.events) != 0 {
+		t.Fatal("an invalid update must not be applied or announced")
+	}
+}
+
+func TestUpdateServiceNotFound(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	other := a.service("API", "Operational")
+	a.db.Services[other.ID].OrganizationID = "org_other"
+
+	for _, id := range []string{"00000000-0000-0000-0000-000000000000", other.ID} {
+		if w := a.do("PUT", "/api/services/"+id, `{"name":"API","status":"Major Outage"}`, "If-Match", `*`); w.Code != http.StatusNotFound {
+			t.Errorf("PUT %s: status %d: %s", id, w.Code, w.Body)
+		}
+	}
+	if a.db.Services[other.ID].Status != "Operational" {
+		t.Error("another organization's service was changed")
+	}
+	if len(a.db.Audit) != 0 || len(a.notifier.events) != 0 {
+		t.Fatal("a failed update must not be audited or announced")
+	}
+}
+
+func TestDeleteAndRestoreService(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleAdmin)
+	s := a.service("API", "Operational")
+
+	if w := a.do("DELETE", "/api/services/"+s.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Services[s.ID]; got == nil || got.ArchivedAt == nil {
+		t.Fatalf("service was not archived: %+v", got)
+	}
+	if w := a.do("GET", "/api/services/"+s.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("archived service: status %d", w.Code)
+	}
+	if w := a.do("DELETE", "/api/services/"+s.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("second delete: status %d", w.Code)
+	}
+	if w := a.do("POST", "/api/services/"+s.ID+"/restore", ""); w.Code != http.StatusOK {
+		t.Fatalf("restore: status %d: %s", w.Code, w.Body)
+	}
+	if w := a.do("GET", "/api/services/"+s.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("restored service: status %d", w.Code)
+	}
+	if w := a.do("POST", "/api/services/"+s.ID+"/restore", ""); w.Code != http.StatusNotFound {
+		t.Errorf("restoring an active service: status %d", w.Code)
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"service.archived", "service.restored"}) {
+		t.Fatalf("audit actions = %v", got)
+	}
+	archived, restored := a.auditChanges(t, 0), a.auditChanges(t, 1)
+	if len(archived) != 2 || archived["archivedAt"].Before != nil || archived["archivedAt"].After == nil || archived["version"].Before != float64(1) {
+		t.Errorf("archive diff = %v, want archivedAt set and version bumped", archived)
+	}
+	if len(restored) != 2 || restored["archivedAt"].Before != archived["archivedAt"].After || restored["archivedAt"].After != nil {
+		t.Errorf("restore diff = %v, want archivedAt cleared and version bumped", restored)
+	}
+	if got := a.notifier.eventTypes(); !reflect.DeepEqual(got, []string{"service_archived", "service_restored"}) {
+		t.Errorf("events = %v", got)
+	}
+}
+
+func TestDeleteServiceRequiresPermission(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+
+	if w := a.do("DELETE", "/api/services/"+s.ID, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if a.db.Services[s.ID].ArchivedAt != nil {
+		t.Fatal("a forbidden delete archived the service")
+	}
+}
+
+func TestGetServiceETag(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleViewer)
+	s := a.service("API", "Operational")
+
+	w := a.do("GET", "/api/services/"+s.ID, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("status %d with ETag %s", w.Code, w.Header().Get("ETag"))
+	}
+	if w := a.do("GET", "/api/services/"+s.ID, "", "If-None-Match", `"1"`); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match current version: status %d", w.Code)
+	}
+	if w := a.do("GET", "/api/services/"+s.ID, "", "If-None-Match", `"0", "2"`); w.Code != http.StatusOK {
+		t.Errorf("If-None-Match other versions: status %d", w.Code)
+	}
+}
+
+func TestUpdateServiceIfMatch(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+
+	w := a.do("PUT", "/api/services/"+s.ID, `{"name":"API","status":"Partial Outage"}`, "If-Match", `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("status %d with ETag %s: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	// a second client still holding version 1
+	w = a.do("PUT", "/api/services/"+s.ID, `{"name":"API","status":"Operational"}`, "If-Match", `"1"`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Version int `json:"version"`
+		Current struct {
+			Status string `json:"status"`
+		} `json:"current"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Version != 2 || body.Current.Status != "Partial Outage" || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("unexpected conflict response %s", w.Body)
+	}
+	if got := a.db.Services[s.ID]; got.Status != "Partial Outage" || got.Version != 2 {
+		t.Errorf("stored service = %+v", got)
+	}
+	if got := a.db.AuditActions(); !reflect.DeepEqual(got, []string{"service.updated"}) {
+		t.Errorf("a conflicting update was audited: %v", got)
+	}
+}
+
+func TestUpdateServiceRequiresIfMatch(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+
+	w := a.do("PUT", "/api/services/"+s.ID, `{"name":"API","status":"Major Outage"}`)
+	if w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Services[s.ID]; got.Status != "Operational" || got.Version != 1 {
+		t.Errorf("stored service = %+v", got)
+	}
+}
+
+func TestUpdateServiceIfMatchList(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+	a.db.Services[s.ID].Version = 3
+
+	w := a.do("PUT", "/api/services/"+s.ID, `{"name":"API","status":"Major Outage"}`, "If-Match", `"2", "3"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Services[s.ID]; got.Status != "Major Outage" || got.Version != 4 {
+		t.Errorf("stored service = %+v", got)
+	}
+}
+
+func TestPatchService(t *testing.T) {
+	a := newTestAPI(t, middleware.RoleResponder)
+	s := a.service("API", "Operational")
+
+	w := a.do("PATCH", "/api/services/"+s.ID, `{"status":"Partial Outage"}`, "Content-Type", "application/merge-patch+json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := a.db.Services[s.ID]; got.Name != "API" || got.Status != "Partial Outage" || got.Version != 2 {
+		t.Errorf("stored service = %+v", got)
+	}
+	if w := a.do("PATCH", "/api/services/"+s.ID, `{"status":"Operational"}`, "Content-Type", "text/plain"); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain patch: status %d", w.Code)
+	}
+}
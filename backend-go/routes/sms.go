@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/notify"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const maxVerificationAttempts = 5
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+func RegisterSMSRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/sms-subscribers", getSMSSubscribers)
+	g.POST("/sms-subscribers", createSMSSubscriber)
+	g.POST("/sms-subscribers/:id/verify", verifySMSSubscriber)
+	g.POST("/sms-subscribers/:id/resend", resendSMSVerification)
+	g.DELETE("/sms-subscribers/:id", deleteSMSSubscriber)
+}
+
+// GET /sms-subscribers
+func getSMSSubscribers(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, phone_number, voice, verified, created_at FROM sms_subscribers WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SMS subscribers"})
+		return
+	}
+	defer rows.Close()
+
+	subscribers := []models.SMSSubscriber{}
+	for rows.Next() {
+		var s models.SMSSubscriber
+		if err := rows.Scan(&s.ID, &s.OrganizationID, &s.PhoneNumber, &s.Voice, &s.Verified, &s.CreatedAt); err == nil {
+			subscribers = append(subscribers, s)
+		}
+	}
+	c.JSON(http.StatusOK, subscribers)
+}
+
+// POST /sms-subscribers (texts a verification code to the number)
+func createSMSSubscriber(c *gin.Context) {
+	var input struct {
+		PhoneNumber string `json:"phoneNumber" binding:"required,phone"`
+		Voice       bool   `json:"voice"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+
+	code, hash, expiresAt, err := notify.NewVerificationCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification code"})
+		return
+	}
+
+	s := models.SMSSubscriber{
+		ID:             uuid.NewString(),
+		OrganizationID: c.GetString("organizationId"),
+		PhoneNumber:    input.PhoneNumber,
+		Voice:          input.Voice,
+	}
+	err = db.DB.QueryRow(`INSERT INTO sms_subscribers (id, organization_id, phone_number, voice, verification_code_hash, verification_expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`,
+		s.ID, s.OrganizationID, s.PhoneNumber, s.Voice, hash, expiresAt).Scan(&s.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number already subscribed"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add SMS subscriber"})
+		return
+	}
+
+	if err := notify.SendVerificationSMS(s.PhoneNumber, code); err != nil {
+		slog.ErrorContext(c.Request.Context(), "could not send verification SMS", "err", err)
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// POST /sms-subscribers/:id/verify
+func verifySMSSubscriber(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+
+	var (
+		verified  bool
+		hash      sql.NullString
+		expiresAt sql.NullTime
+		attempts  int
+	)
+	err := db.DB.QueryRow(`SELECT verified, verification_code_hash, verification_expires_at, verification_attempts FROM sms_subscribers WHERE id = $1 AND organization_id = $2`, id, orgID).
+		Scan(&verified, &hash, &expiresAt, &attempts)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SMS subscriber not found or not owned by org"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify SMS subscriber"})
+		return
+	}
+	if verified {
+		c.JSON(http.StatusOK, gin.H{"id": id, "verified": true})
+		return
+	}
+	if !hash.Valid || !expiresAt.Valid || time.Now().After(expiresAt.Time) || attempts >= maxVerificationAttempts {
+		writeFieldErrors(c, fieldError{Field: "code", Code: "expired", Message: "Verification code expired, request a new one"})
+		return
+	}
+	if !notify.CheckVerificationCode(input.Code, hash.String) {
+		_, _ = db.DB.Exec(`UPDATE sms_subscribers SET verification_attempts = verification_attempts + 1 WHERE id = $1`, id)
+		writeFieldErrors(c, fieldError{Field: "code", Code: "mismatch", Message: "Invalid verification code"})
+		return
+	}
+
+	_, err = db.DB.Exec(`UPDATE sms_subscribers SET verified = true, verification_code_hash = NULL, verification_expires_at = NULL WHERE id = $1`, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify SMS subscriber"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "verified": true})
+}
+
+// POST /sms-subscribers/:id/resend
+func resendSMSVerification(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+
+	code, hash, expiresAt, err := notify.NewVerificationCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification code"})
+		return
+	}
+	var phone string
+	err = db.DB.QueryRow(`UPDATE sms_subscribers SET verification_code_hash = $1, verification_expires_at = $2, verification_attempts = 0
+		WHERE id = $3 AND organization_id = $4 AND NOT verified RETURNING phone_number`, hash, expiresAt, id, orgID).Scan(&phone)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unverified SMS subscriber not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend verification code"})
+		return
+	}
+
+	err = notify.SendVerificationSMS(phone, code)
+	if errors.Is(err, notify.ErrSMSRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many messages sent to this number, try again later"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "could not send verification SMS", "err", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send verification code"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "sent": true})
+}
+
+// DELETE /sms-subscribers/:id
+func deleteSMSSubscriber(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`DELETE FROM sms_subscribers WHERE id=$1 AND organization_id=$2`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete SMS subscriber"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SMS subscriber not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{1,61}[a-z0-9])$`)
+
+func RegisterStatusPageRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/status-page", getStatusPage)
+	g.PUT("/status-page", updateStatusPage)
+}
+
+// GET /status-page
+func getStatusPage(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	var slug string
+	err := db.DB.QueryRow(`SELECT slug FROM status_pages WHERE organization_id = $1`, orgID).Scan(&slug)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No status page configured"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status page"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"organizationId": orgID, "slug": slug})
+}
+
+// PUT /status-page (claims or changes the org's public slug)
+func updateStatusPage(c *gin.Context) {
+	var input struct {
+		Slug string `json:"slug" binding:"required,slug"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+	orgID := c.GetString("organizationId")
+	_, err := db.DB.Exec(`INSERT INTO status_pages (organization_id, slug) VALUES ($1, $2)
+		ON CONFLICT (organization_id) DO UPDATE SET slug = EXCLUDED.slug`, orgID, input.Slug)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug already taken"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status page"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"organizationId": orgID, "slug": input.Slug})
+}
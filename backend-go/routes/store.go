@@ -0,0 +1,12 @@
+package routes
+
+import "backend-go/store"
+
+// stores is the data access used by the service and incident handlers. main
+// installs the Postgres implementation; tests can install fakes.
+var stores store.Stores
+
+// UseStores sets the stores the handlers use.
+func UseStores(s store.Stores) {
+	stores = s
+}
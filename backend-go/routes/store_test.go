@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"backend-go/models"
+	"backend-go/store"
+	"backend-go/store/storetest"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const testOrg = "org_test"
+
+// testAPI serves the service, incident and audit log routes over in-memory stores, as a
+// caller with the given role in testOrg.
+type testAPI struct {
+	router   *gin.Engine
+	db       *storetest.Memory
+	notifier *fakeNotifier
+}
+
+func newTestAPI(t *testing.T, role string) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	a := &testAPI{router: gin.New(), db: storetest.New(), notifier: &fakeNotifier{}}
+
+	prevStores, prevNotifier := stores, notifier
+	UseStores(a.db.Stores())
+	UseNotifier(a.notifier)
+	t.Cleanup(func() {
+		stores, notifier = prevStores, prevNotifier
+	})
+
+	api := a.router.Group("/api", func(c *gin.Context) {
+		c.Set("organizationId", testOrg)
+		c.Set("userId", "user_test")
+		c.Set("role", role)
+		c.Next()
+	})
+	RegisterServiceRoutes(api)
+	RegisterIncidentRoutes(api)
+	RegisterAuditRoutes(api)
+	return a
+}
+
+// do sends a request; headers are name, value pairs.
+func (a *testAPI) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// service adds a service of testOrg to the store.
+func (a *testAPI) service(name, status string) models.Service {
+	s := models.Service{ID: uuid.NewString(), Name: name, Status: status, OrganizationID: testOrg}
+	_ = stores.Services.Create(context.Background(), s)
+	return s
+}
+
+// auditChanges decodes the diff of the i-th audit entry.
+func (a *testAPI) auditChanges(t *testing.T, i int) map[string]fieldChange {
+	t.Helper()
+	var changes map[string]fieldChange
+	if err := json.Unmarshal(a.db.Audit[i].Changes, &changes); err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+// incident adds an open incident of testOrg affecting serviceIDs to the store.
+func (a *testAPI) incident(title string, serviceIDs ...string) string {
+	id, _ := stores.Incidents.Create(context.Background(), testOrg, store.IncidentChange{
+		Title: title, Type: "incident", Status: "Investigating", ServiceIDs: serviceIDs,
+	})
+	return id
+}
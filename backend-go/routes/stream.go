@@ -1,77 +1,105 @@
 package routes
 
 import (
+	"backend-go/db"
+	"backend-go/events"
+	"backend-go/middleware"
+	"database/sql"
+	"encoding/json"
 	"fmt"
-	
-	"sync"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-type sseClient struct {
-	ch   chan string
-	quit chan struct{}
-}
+const (
+	sseHeartbeat = 15 * time.Second
+	// reconnect delay suggested to browsers
+	sseRetry = 3 * time.Second
+)
 
-type sseHub struct {
-	clients map[*sseClient]struct{}
-	lock    sync.Mutex
+// RegisterStreamRoutes registers the dashboard stream; rg must be authenticated.
+func RegisterStreamRoutes(rg *gin.RouterGroup) {
+	rg.GET("/stream", middleware.RequirePermission(middleware.PermServicesRead), sseStream)
 }
 
-var hub = &sseHub{clients: make(map[*sseClient]struct{})}
-
-func (h *sseHub) Add(c *sseClient) {
-	h.lock.Lock()
-	h.clients[c] = struct{}{}
-	h.lock.Unlock()
+// RegisterPublicStreamRoutes registers the unauthenticated status page stream.
+func RegisterPublicStreamRoutes(rg *gin.RouterGroup) {
+	rg.GET("/public/stream/:slug", publicSSEStream)
 }
 
-func (h *sseHub) Remove(c *sseClient) {
-	h.lock.Lock()
-	delete(h.clients, c)
-	h.lock.Unlock()
+// GET /stream (every event of the caller's organization)
+func sseStream(c *gin.Context) {
+	serveSSE(c, c.GetString("organizationId"), false)
 }
 
-func (h *sseHub) Broadcast(msg string) {
-	h.lock.Lock()
-	for c := range h.clients {
-		select {
-		case c.ch <- msg:
-		default:
-		}
+// GET /public/stream/:slug (public-safe events of the org owning the status page)
+func publicSSEStream(c *gin.Context) {
+	var orgID string
+	err := db.DB.QueryRow(`SELECT organization_id FROM status_pages WHERE slug = $1`, c.Param("slug")).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not found"})
+		return
 	}
-	h.lock.Unlock()
-}
-
-// Call this from other handlers to broadcast updates
-func BroadcastSSE(msg string) {
-	hub.Broadcast(msg)
-}
-
-func RegisterStreamRoutes(rg *gin.RouterGroup) {
-	rg.GET("/stream", sseStream)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load status page"})
+		return
+	}
+	serveSSE(c, orgID, true)
 }
 
-func sseStream(c *gin.Context) {
-	client := &sseClient{ch: make(chan string, 10), quit: make(chan struct{})}
-	hub.Add(client)
-	defer hub.Remove(client)
+// serveSSE streams an org's events. Each event carries an id: so browsers resend it
+// as Last-Event-ID on reconnect and missed events are replayed from the hub's buffer;
+// if they are no longer buffered a "resync" event tells the client to refetch.
+func serveSSE(c *gin.Context, orgID string, publicOnly bool) {
+	lastSeq, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+	sub, replay, complete := events.Default.Subscribe(orgID, publicOnly, lastSeq)
+	defer events.Default.Unsubscribe(sub)
 
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
 	c.Writer.Header().Set("Cache-Control", "no-cache")
 	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry.Milliseconds())
+	if !complete {
+		fmt.Fprint(c.Writer, "event: resync\ndata: {}\n\n")
+	}
+	for _, e := range replay {
+		writeSSE(c.Writer, e)
+	}
 	c.Writer.Flush()
 
-	notify := c.Writer.CloseNotify()
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
 	for {
 		select {
-		case msg := <-client.ch:
-			fmt.Fprintf(c.Writer, "data: %s\n\n", msg)
+		case e := <-sub.C:
+			writeSSE(c.Writer, e)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
 			c.Writer.Flush()
-		case <-notify:
+		case <-sub.Dropped:
+			// too far behind; the client reconnects and replays
 			return
-		case <-client.quit:
+		case <-events.Default.Done():
+			// shutting down; the client reconnects after sseRetry
+			return
+		case <-ctx.Done():
 			return
 		}
 	}
-} 
\ No newline at end of file
+}
+
+func writeSSE(w io.Writer, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+}
@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/notify"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func RegisterSubscriberRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/email-subscribers", getEmailSubscribers)
+	g.POST("/email-subscribers", createEmailSubscriber)
+	g.PUT("/email-subscribers/:id", updateEmailSubscriber)
+	g.DELETE("/email-subscribers/:id", deleteEmailSubscriber)
+	g.GET("/notification-settings", getNotificationSettings)
+	g.PUT("/notification-settings", updateNotificationSettings)
+}
+
+type emailSubscriberInput struct {
+	Email           string  `json:"email" binding:"required,email"`
+	Delivery        *string `json:"delivery" binding:"omitempty,delivery"`
+	Timezone        string  `json:"timezone" binding:"omitempty,timezone"`
+	QuietHoursStart *string `json:"quietHoursStart" binding:"required_with=QuietHoursEnd,omitempty,clock"`
+	QuietHoursEnd   *string `json:"quietHoursEnd" binding:"required_with=QuietHoursStart,omitempty,clock"`
+}
+
+// normalize fills in the default time zone.
+func (in *emailSubscriberInput) normalize() {
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+}
+
+// GET /email-subscribers
+func getEmailSubscribers(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, email, delivery, timezone, quiet_hours_start, quiet_hours_end, created_at FROM email_subscribers WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email subscribers"})
+		return
+	}
+	defer rows.Close()
+
+	subscribers := []models.EmailSubscriber{}
+	for rows.Next() {
+		var s models.EmailSubscriber
+		if err := rows.Scan(&s.ID, &s.OrganizationID, &s.Email, &s.Delivery, &s.Timezone, &s.QuietHoursStart, &s.QuietHoursEnd, &s.CreatedAt); err == nil {
+			subscribers = append(subscribers, s)
+		}
+	}
+	c.JSON(http.StatusOK, subscribers)
+}
+
+// POST /email-subscribers (delivery null means use the org default)
+func createEmailSubscriber(c *gin.Context) {
+	var input emailSubscriberInput
+	if !bindBody(c, &input) {
+		return
+	}
+	input.normalize()
+
+	s := models.EmailSubscriber{
+		ID:              uuid.NewString(),
+		OrganizationID:  c.GetString("organizationId"),
+		Email:           input.Email,
+		Delivery:        input.Delivery,
+		Timezone:        input.Timezone,
+		QuietHoursStart: input.QuietHoursStart,
+		QuietHoursEnd:   input.QuietHoursEnd,
+	}
+	err := db.DB.QueryRow(`INSERT INTO email_subscribers (id, organization_id, email, delivery, timezone, quiet_hours_start, quiet_hours_end) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`,
+		s.ID, s.OrganizationID, s.Email, s.Delivery, s.Timezone, s.QuietHoursStart, s.QuietHoursEnd).Scan(&s.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already subscribed"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add email subscriber"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// PUT /email-subscribers/:id
+func updateEmailSubscriber(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	var input emailSubscriberInput
+	if !bindBody(c, &input) {
+		return
+	}
+	input.normalize()
+
+	res, err := db.DB.Exec(`UPDATE email_subscribers SET email=$1, delivery=$2, timezone=$3, quiet_hours_start=$4, quiet_hours_end=$5 WHERE id=$6 AND organization_id=$7`,
+		input.Email, input.Delivery, input.Timezone, input.QuietHoursStart, input.QuietHoursEnd, id, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email subscriber"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email subscriber not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, models.EmailSubscriber{
+		ID:              id,
+		OrganizationID:  orgID,
+		Email:           input.Email,
+		Delivery:        input.Delivery,
+		Timezone:        input.Timezone,
+		QuietHoursStart: input.QuietHoursStart,
+		QuietHoursEnd:   input.QuietHoursEnd,
+	})
+}
+
+// DELETE /email-subscribers/:id
+func deleteEmailSubscriber(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`DELETE FROM email_subscribers WHERE id=$1 AND organization_id=$2`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete email subscriber"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email subscriber not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// GET /notification-settings
+func getNotificationSettings(c *gin.Context) {
+	settings := models.NotificationSettings{OrganizationID: c.GetString("organizationId"), DefaultDelivery: notify.DeliveryImmediate}
+	err := db.DB.QueryRow(`SELECT default_delivery FROM notification_settings WHERE organization_id = $1`, settings.OrganizationID).Scan(&settings.DefaultDelivery)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification settings"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// PUT /notification-settings
+func updateNotificationSettings(c *gin.Context) {
+	var input struct {
+		DefaultDelivery string `json:"defaultDelivery" binding:"required,delivery"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+	orgID := c.GetString("organizationId")
+	_, err := db.DB.Exec(`INSERT INTO notification_settings (organization_id, default_delivery) VALUES ($1, $2)
+		ON CONFLICT (organization_id) DO UPDATE SET default_delivery = EXCLUDED.default_delivery, updated_at = now()`, orgID, input.DefaultDelivery)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification settings"})
+		return
+	}
+	c.JSON(http.StatusOK, models.NotificationSettings{OrganizationID: orgID, DefaultDelivery: input.DefaultDelivery})
+}
@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"backend-go/middleware"
+	"backend-go/notify"
+	"backend-go/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Codes in the error envelope of a 400 response. Field errors use the name of
+// the rule that failed, e.g. "required", "max" or "service_status".
+const (
+	codeInvalidBody      = "invalid_body"
+	codeValidationFailed = "validation_failed"
+	codeInvalidQuery     = "invalid_query"
+	codeUnknownService   = "unknown_service"
+)
+
+// fieldError is one invalid field of a request body. Field is its JSON path,
+// e.g. "serviceIds[1]" or "mapping.status".
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// customRules are the validation tags this package adds to gin's validator.
+var customRules = map[string]func(string) bool{
+	"service_status":  isValidStatus,
+	"incident_status": isValidIncidentStatus,
+	"role":            middleware.IsValidRole,
+	"api_scope":       middleware.IsValidScope,
+	"delivery":        notify.IsValidDelivery,
+	"phone":           e164.MatchString,
+	"slug":            slugPattern.MatchString,
+	"clock":           func(s string) bool { _, err := notify.ParseClock(s); return err == nil },
+	"jsonpath":        func(s string) bool { return utils.ValidateJSONPath(s) == nil },
+	"alert_matcher":   func(s string) bool { _, err := parseMatcher(s); return err == nil },
+	// empty passes so optional URLs need only omitempty on pointers; add required where needed
+	"http_url": func(s string) bool { return s == "" || isValidWebhookURL(s) },
+}
+
+// ruleMessages complete "<field> ..." for each rule; %s is the rule's parameter.
+var ruleMessages = map[string]string{
+	"required":         "is required",
+	"required_with":    "is required when %s is set",
+	"required_without": "is required when %s is not set",
+	"oneof":            "must be one of: %s",
+	"uuid":             "must be a UUID",
+	"email":            "must be an email address",
+	"timezone":         "must be an IANA time zone, e.g. Europe/Berlin",
+	"service_status":   "must be Operational, Degraded Performance, Partial Outage or Major Outage",
+	"incident_status":  "must be Investigating, Identified, Monitoring, Resolved, Scheduled, In Progress or Completed",
+	"role":             "must be admin, responder or viewer",
+	"api_scope":        "must be read, services:write, incidents:write or admin",
+	"delivery":         "must be immediate, hourly or daily",
+	"phone":            "must be in E.164 format, e.g. +14155550123",
+	"slug":             "must be 3-63 lowercase letters, digits or dashes",
+	"clock":            "must use HH:MM",
+	"jsonpath":         "must be a JSONPath expression such as $.service.name",
+	"alert_matcher":    `must look like label="value", label!="value", label=~"regex" or label!~"regex"`,
+	"http_url":         "must be an http or https URL",
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(jsonFieldName)
+	for tag, valid := range customRules {
+		valid := valid
+		v.RegisterValidation(tag, func(fl validator.FieldLevel) bool { return valid(fl.Field().String()) })
+	}
+}
+
+// jsonFieldName names fields in errors after their JSON keys.
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// bindBody decodes the JSON body into obj and checks its binding tags. On
+// failure it responds 400 with the error envelope and returns false.
+func bindBody(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	var (
+		verrs   validator.ValidationErrors
+		typeErr *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &verrs):
+		writeFieldErrors(c, validationFieldErrors(obj, verrs)...)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		writeFieldErrors(c, fieldError{Field: typeErr.Field, Code: "type", Message: typeErr.Field + " must be " + jsonTypeName(typeErr.Type)})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body", "code": codeInvalidBody})
+	}
+	return false
+}
+
+// validateBody checks the binding tags of a body that was not decoded by
+// bindBody, such as the result of a merge patch.
+func validateBody(c *gin.Context, obj interface{}) bool {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return true
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		slog.ErrorContext(c.Request.Context(), "validation failed", "err", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid body", "code": codeInvalidBody})
+		return false
+	}
+	writeFieldErrors(c, validationFieldErrors(obj, verrs)...)
+	return false
+}
+
+// writeFieldErrors responds 400 with
+// {"error": "<first message>", "code": "validation_failed", "fields": [...]}.
+func writeFieldErrors(c *gin.Context, fields ...fieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  fields[0].Message,
+		"code":   codeValidationFailed,
+		"fields": fields,
+	})
+}
+
+// validationFieldErrors converts the errors from validating obj.
+func validationFieldErrors(obj interface{}, verrs validator.ValidationErrors) []fieldError {
+	root := reflect.TypeOf(obj)
+	for root.Kind() == reflect.Ptr {
+		root = root.Elem()
+	}
+	fields := make([]fieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		// namespaces start with the name of the bound struct, if it has one
+		field := strings.TrimPrefix(fe.Namespace(), root.Name()+".")
+		fields = append(fields, fieldError{Field: field, Code: fe.Tag(), Message: field + " " + ruleMessage(root, fe)})
+	}
+	return fields
+}
+
+func ruleMessage(root reflect.Type, fe validator.FieldError) string {
+	if msg, ok := ruleMessages[fe.Tag()]; ok {
+		if strings.Contains(msg, "%s") {
+			param := fe.Param()
+			if strings.HasPrefix(fe.Tag(), "required_with") {
+				param = siblingJSONName(root, fe.StructNamespace(), param)
+			}
+			return fmt.Sprintf(msg, param)
+		}
+		return msg
+	}
+	switch fe.Tag() {
+	case "max", "min":
+		bound := "at most"
+		if fe.Tag() == "min" {
+			bound = "at least"
+		}
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters", bound, fe.Param())
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	}
+	return "fails the " + fe.Tag() + " rule"
+}
+
+// siblingJSONName returns the JSON name of the Go field named name next to the
+// field at structNamespace, e.g. "quietHoursEnd" for QuietHoursEnd.
+func siblingJSONName(root reflect.Type, structNamespace, name string) string {
+	t := root
+	segments := strings.Split(structNamespace, ".")
+	for _, seg := range segments[1 : len(segments)-1] {
+		seg, _, _ = strings.Cut(seg, "[")
+		f, ok := t.FieldByName(seg)
+		if !ok {
+			return name
+		}
+		for t = f.Type; t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice; t = t.Elem() {
+		}
+	}
+	if f, ok := t.FieldByName(name); ok {
+		return jsonFieldName(f)
+	}
+	return name
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a " + t.String()
+}
+
+// serviceRef is a service ID referenced at a position in a request body.
+type serviceRef struct {
+	field string
+	id    string
+}
+
+// checkServiceRefs responds 400 unless every referenced service is an active
+// service of the caller's org, so no org can link another org's services.
+func checkServiceRefs(c *gin.Context, refs ...serviceRef) bool {
+	if len(refs) == 0 {
+		return true
+	}
+	ids := make([]string, len(refs))
+	for i, r := range refs {
+		ids[i] = r.id
+	}
+	unknown, err := stores.Services.Unknown(c.Request.Context(), c.GetString("organizationId"), ids)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check services"})
+		return false
+	}
+	if len(unknown) == 0 {
+		return true
+	}
+	missing := make(map[string]bool, len(unknown))
+	for _, id := range unknown {
+		missing[id] = true
+	}
+	var fields []fieldError
+	for _, r := range refs {
+		if missing[r.id] {
+			fields = append(fields, fieldError{Field: r.field, Code: codeUnknownService, Message: r.field + " is not a service of this organization"})
+		}
+	}
+	writeFieldErrors(c, fields...)
+	return false
+}
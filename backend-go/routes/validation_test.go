@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func validationErrorsOf(t *testing.T, obj interface{}) []fieldError {
+	t.Helper()
+	var verrs validator.ValidationErrors
+	if !errors.As(binding.Validator.ValidateStruct(obj), &verrs) {
+		t.Fatalf("expected validation errors for %+v", obj)
+	}
+	return validationFieldErrors(obj, verrs)
+}
+
+func TestValidationFieldErrors(t *testing.T) {
+	type quietHours struct {
+		Start string `json:"start" binding:"omitempty,clock"`
+	}
+	type subscriber struct {
+		Email         string       `json:"email" binding:"required,email"`
+		Delivery      string       `json:"delivery" binding:"omitempty,delivery"`
+		QuietHoursEnd string       `json:"quietHoursEnd"`
+		QuietStart    string       `json:"quietHoursStart" binding:"required_with=QuietHoursEnd"`
+		Windows       []quietHours `json:"windows" binding:"dive"`
+		Tags          []string     `json:"tags" binding:"max=2"`
+	}
+
+	cases := []struct {
+		name string
+		obj  interface{}
+		want []fieldError
+	}{
+		{
+			"incident rules",
+			&incidentInput{Title: "", Type: "outage", Status: "Investigating", ServiceIDs: []string{"not-a-uuid"}},
+			[]fieldError{
+				{"title", "required", "title is required"},
+				{"type", "oneof", "type must be one of: incident maintenance"},
+				{"serviceIds[0]", "uuid", "serviceIds[0] must be a UUID"},
+			},
+		},
+		{
+			"custom rule",
+			&serviceInput{Name: "API", Status: "Sideways"},
+			[]fieldError{{"status", "service_status", "status must be Operational, Degraded Performance, Partial Outage or Major Outage"}},
+		},
+		{
+			"string length",
+			&serviceInput{Name: string(make([]byte, 101)), Status: "Operational"},
+			[]fieldError{{"name", "max", "name must be at most 100 characters"}},
+		},
+		{
+			"nested, sibling and slice length",
+			&subscriber{
+				Email:         "nope",
+				Delivery:      "weekly",
+				QuietHoursEnd: "07:00",
+				Windows:       []quietHours{{Start: "7am"}},
+				Tags:          []string{"a", "b", "c"},
+			},
+			[]fieldError{
+				{"email", "email", "email must be an email address"},
+				{"delivery", "delivery", "delivery must be immediate, hourly or daily"},
+				{"quietHoursStart", "required_with", "quietHoursStart is required when quietHoursEnd is set"},
+				{"windows[0].start", "clock", "windows[0].start must use HH:MM"},
+				{"tags", "max", "tags must have at most 2 items"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := validationErrorsOf(t, tc.obj); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"backend-go/db"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/notify"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func RegisterWebhookRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("", middleware.RequirePermission(middleware.PermOrgManage))
+	g.GET("/webhooks", getWebhooks)
+	g.POST("/webhooks", createWebhook)
+	g.PUT("/webhooks/:id", updateWebhook)
+	g.DELETE("/webhooks/:id", deleteWebhook)
+	g.GET("/webhooks/:id/deliveries", getWebhookDeliveries)
+}
+
+func isValidWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// GET /webhooks (secrets are only returned on creation)
+func getWebhooks(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	rows, err := db.DB.Query(`SELECT id, organization_id, url, events, enabled, consecutive_failures, disabled_at, created_at, updated_at
+		FROM webhook_endpoints WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+	defer rows.Close()
+
+	webhooks := []models.WebhookEndpoint{}
+	for rows.Next() {
+		var w models.WebhookEndpoint
+		if err := rows.Scan(&w.ID, &w.OrganizationID, &w.URL, pq.Array(&w.Events), &w.Enabled, &w.ConsecutiveFailures, &w.DisabledAt, &w.CreatedAt, &w.UpdatedAt); err == nil {
+			webhooks = append(webhooks, w)
+		}
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// POST /webhooks
+func createWebhook(c *gin.Context) {
+	var input struct {
+		URL    string   `json:"url" binding:"required,http_url"`
+		Events []string `json:"events" binding:"max=50,dive,required"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+	if input.Events == nil {
+		input.Events = []string{}
+	}
+
+	secret, err := notify.NewWebhookSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	w := models.WebhookEndpoint{
+		ID:             uuid.NewString(),
+		OrganizationID: c.GetString("organizationId"),
+		URL:            input.URL,
+		Secret:         secret,
+		Events:         input.Events,
+		Enabled:        true,
+	}
+	err = db.DB.QueryRow(`INSERT INTO webhook_endpoints (id, organization_id, url, secret, events) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at`,
+		w.ID, w.OrganizationID, w.URL, w.Secret, pq.Array(w.Events)).Scan(&w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "insert failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// PUT /webhooks/:id (re-enabling an endpoint resets its failure count;
+// omitting "enabled" leaves the current state alone)
+func updateWebhook(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	var input struct {
+		URL     string   `json:"url" binding:"required,http_url"`
+		Events  []string `json:"events" binding:"max=50,dive,required"`
+		Enabled *bool    `json:"enabled"`
+	}
+	if !bindBody(c, &input) {
+		return
+	}
+	if input.Events == nil {
+		input.Events = []string{}
+	}
+
+	var enabled bool
+	err := db.DB.QueryRow(`UPDATE webhook_endpoints SET url=$1, events=$2, enabled=COALESCE($3::boolean, enabled),
+			consecutive_failures = CASE WHEN $3::boolean THEN 0 ELSE consecutive_failures END,
+			disabled_at = CASE WHEN $3::boolean THEN NULL ELSE disabled_at END,
+			updated_at=now()
+		WHERE id=$4 AND organization_id=$5
+		RETURNING enabled`,
+		input.URL, pq.Array(input.Events), input.Enabled, id, orgID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found or not owned by org"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "update failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "url": input.URL, "events": input.Events, "enabled": enabled})
+}
+
+// DELETE /webhooks/:id
+func deleteWebhook(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	res, err := db.DB.Exec(`DELETE FROM webhook_endpoints WHERE id=$1 AND organization_id=$2`, id, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found or not owned by org"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// GET /webhooks/:id/deliveries (most recent first)
+func getWebhookDeliveries(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	id := c.Param("id")
+	rows, err := db.DB.Query(`SELECT d.id, d.endpoint_id, d.event, d.payload, d.attempt, d.status_code, d.success, d.error, d.duration_ms, d.created_at
+		FROM webhook_deliveries d JOIN webhook_endpoints w ON w.id = d.endpoint_id
+		WHERE d.endpoint_id = $1 AND w.organization_id = $2
+		ORDER BY d.created_at DESC LIMIT 100`, id, orgID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "DB error", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.Event, &d.Payload, &d.Attempt, &d.StatusCode, &d.Success, &d.Error, &d.DurationMs, &d.CreatedAt); err == nil {
+			deliveries = append(deliveries, d)
+		}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
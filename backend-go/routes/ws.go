@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"backend-go/events"
+	"backend-go/middleware"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const wsWriteTimeout = 10 * time.Second
+
+// RegisterWebSocketRoutes registers the WebSocket endpoint; rg must be authenticated.
+func RegisterWebSocketRoutes(rg *gin.RouterGroup) {
+	rg.GET("/ws", middleware.RequirePermission(middleware.PermServicesRead), wsStream)
+}
+
+// wsMessage is the envelope for both directions of the WebSocket protocol:
+//
+//	client: {"type":"subscribe","services":[...],"incidents":[...],"events":[...]}
+//	        {"type":"unsubscribe"} | {"type":"ping"}
+//	server: {"type":"subscribed",...} | {"type":"event","event":{...}} | {"type":"pong"} | {"type":"error","message":"..."}
+type wsMessage struct {
+	Type      string        `json:"type"`
+	Services  []string      `json:"services,omitempty"`
+	Incidents []string      `json:"incidents,omitempty"`
+	Events    []string      `json:"events,omitempty"`
+	Event     *events.Event `json:"event,omitempty"`
+	Message   string        `json:"message,omitempty"`
+}
+
+// wsFilter selects events for a connection. An empty list places no restriction
+// on that dimension; services and incidents together match either.
+type wsFilter struct {
+	active    bool
+	services  map[string]bool
+	incidents map[string]bool
+	types     map[string]bool
+}
+
+func newWSFilter(m wsMessage) wsFilter {
+	f := wsFilter{active: true, services: map[string]bool{}, incidents: map[string]bool{}, types: map[string]bool{}}
+	for _, id := range m.Services {
+		f.services[id] = true
+	}
+	for _, id := range m.Incidents {
+		f.incidents[id] = true
+	}
+	for _, t := range m.Events {
+		f.types[t] = true
+	}
+	return f
+}
+
+func (f wsFilter) matches(e events.Event) bool {
+	if !f.active {
+		return false
+	}
+	if len(f.types) > 0 && !f.types[e.Type] {
+		return false
+	}
+	if len(f.services) == 0 && len(f.incidents) == 0 {
+		return true
+	}
+	if f.services[e.ID] || f.incidents[e.ID] {
+		return true
+	}
+	for _, id := range e.Related {
+		if f.services[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /ws (typed org events, filtered by subscribe messages)
+func wsStream(c *gin.Context) {
+	orgID := c.GetString("organizationId")
+	server := websocket.Server{
+		// Authentication already happened via the bearer token; accept any Origin.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			serveWebSocket(c, conn, orgID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func serveWebSocket(c *gin.Context, conn *websocket.Conn, orgID string) {
+	defer conn.Close()
+
+	sub, _, _ := events.Default.Subscribe(orgID, false, 0)
+	defer events.Default.Unsubscribe(sub)
+
+	var (
+		filter wsFilter
+		lock   sync.Mutex
+	)
+	out := make(chan wsMessage, 16)
+	done := make(chan struct{})
+
+	// reader: applies subscription changes and answers pings
+	go func() {
+		defer close(done)
+		for {
+			var msg wsMessage
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				return
+			}
+			var reply wsMessage
+			switch msg.Type {
+			case "subscribe":
+				lock.Lock()
+				filter = newWSFilter(msg)
+				lock.Unlock()
+				reply = wsMessage{Type: "subscribed", Services: msg.Services, Incidents: msg.Incidents, Events: msg.Events}
+			case "unsubscribe":
+				lock.Lock()
+				filter = wsFilter{}
+				lock.Unlock()
+				reply = wsMessage{Type: "unsubscribed"}
+			case "ping":
+				reply = wsMessage{Type: "pong"}
+			default:
+				reply = wsMessage{Type: "error", Message: "unknown message type " + msg.Type}
+			}
+			select {
+			case out <- reply:
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+	}()
+
+	// writer: the only goroutine writing to conn
+	for {
+		var msg wsMessage
+		select {
+		case e := <-sub.C:
+			lock.Lock()
+			ok := filter.matches(e)
+			lock.Unlock()
+			if !ok {
+				continue
+			}
+			msg = wsMessage{Type: "event", Event: &e}
+		case msg = <-out:
+		case <-sub.Dropped:
+			_ = websocket.JSON.Send(conn, wsMessage{Type: "error", Message: "connection fell behind, reconnect and refetch"})
+			return
+		case <-events.Default.Done():
+			_ = websocket.JSON.Send(conn, wsMessage{Type: "error", Message: "server shutting down, reconnect"})
+			return
+		case <-done:
+			return
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := websocket.JSON.Send(conn, msg); err != nil {
+			return
+		}
+	}
+}
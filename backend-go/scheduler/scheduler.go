@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// staleAfter is how many intervals a job may go without a successful run
+// before Check reports the scheduler unhealthy.
+const staleAfter = 3
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       func() error
+
+	lastSuccess time.Time
+	lastErr     error
+}
+
+// Scheduler runs background jobs at fixed intervals.
+type Scheduler struct {
+	jobs    []*job
+	lock    sync.Mutex
+	ctx     context.Context
+	started time.Time
+	running sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers fn to run once per interval after Start is called.
+func (s *Scheduler) Every(name string, interval time.Duration, fn func() error) {
+	s.lock.Lock()
+	s.jobs = append(s.jobs, &job{name: name, interval: interval, fn: fn})
+	s.lock.Unlock()
+}
+
+// Start launches every registered job; they stop when ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ctx = ctx
+	s.started = time.Now()
+	for _, j := range s.jobs {
+		s.running.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Wait blocks until every job has stopped after ctx passed to Start is
+// cancelled, letting runs in progress finish, or until waitCtx expires.
+func (s *Scheduler) Wait(waitCtx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-waitCtx.Done():
+		return waitCtx.Err()
+	}
+}
+
+// Check returns an error unless the scheduler is running and every job has
+// succeeded within staleAfter intervals, counting from Start for new jobs.
+func (s *Scheduler) Check() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ctx == nil {
+		return errors.New("scheduler not started")
+	}
+	if s.ctx.Err() != nil {
+		return errors.New("scheduler stopped")
+	}
+	now := time.Now()
+	for _, j := range s.jobs {
+		last := j.lastSuccess
+		if last.IsZero() {
+			last = s.started
+		}
+		if now.Sub(last) <= staleAfter*j.interval {
+			continue
+		}
+		if j.lastErr != nil {
+			return fmt.Errorf("job %s failing: %w", j.name, j.lastErr)
+		}
+		return fmt.Errorf("job %s has not completed for %s", j.name, now.Sub(last).Round(time.Second))
+	}
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.running.Done()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := j.fn()
+			if err != nil {
+				slog.Error("scheduled job failed", "job", j.name, "err", err)
+			}
+			s.lock.Lock()
+			j.lastErr = err
+			if err == nil {
+				j.lastSuccess = time.Now()
+			}
+			s.lock.Unlock()
+		}
+	}
+}
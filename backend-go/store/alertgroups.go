@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type pgAlertGroups struct {
+	db *sql.DB
+}
+
+func (p *pgAlertGroups) Incident(ctx context.Context, receiverID, groupKey string) (string, error) {
+	var id sql.NullString
+	err := p.db.QueryRowContext(ctx, `SELECT incident_id FROM alertmanager_incidents WHERE receiver_id = $1 AND group_key = $2`,
+		receiverID, groupKey).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id.String, err
+}
+
+func (p *pgAlertGroups) Open(ctx context.Context, orgID, receiverID, groupKey string, ch IncidentChange) (string, bool, error) {
+	var (
+		id      string
+		created bool
+	)
+	err := inTx(ctx, p.db, func(tx *sql.Tx) error {
+		// Take the group row before anything else: a concurrent notification for the
+		// same group blocks here until this transaction commits, then sees its incident.
+		_, err := tx.ExecContext(ctx, `INSERT INTO alertmanager_incidents (receiver_id, group_key) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			receiverID, groupKey)
+		if err != nil {
+			return err
+		}
+		var current sql.NullString
+		err = tx.QueryRowContext(ctx, `SELECT incident_id FROM alertmanager_incidents WHERE receiver_id = $1 AND group_key = $2 FOR UPDATE`,
+			receiverID, groupKey).Scan(&current)
+		if err != nil {
+			return err
+		}
+		if current.Valid {
+			var open bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM incidents WHERE id = $1 AND NOT is_resolved AND archived_at IS NULL)`,
+				current.String).Scan(&open)
+			if err != nil {
+				return err
+			}
+			if open {
+				id = current.String
+				return nil
+			}
+		}
+
+		id, err = insertIncident(ctx, tx, orgID, ch)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE alertmanager_incidents SET incident_id = $3, created_at = now() WHERE receiver_id = $1 AND group_key = $2`,
+			receiverID, groupKey, id)
+		created = err == nil
+		return err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return id, created, nil
+}
+
+func (p *pgAlertGroups) Release(ctx context.Context, receiverID, groupKey, incidentID string) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM alertmanager_incidents WHERE receiver_id = $1 AND group_key = $2 AND incident_id = $3`,
+		receiverID, groupKey, incidentID)
+	return err
+}
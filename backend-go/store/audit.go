@@ -0,0 +1,69 @@
+package store
+
+import (
+	"backend-go/models"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type pgAudit struct {
+	db *sql.DB
+}
+
+func (p *pgAudit) Record(ctx context.Context, e models.AuditEntry) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO audit_log (id, organization_id, actor_type, actor_id, action, target_type, target_id, changes, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		uuid.NewString(), e.OrganizationID, e.ActorType, e.ActorID, e.Action, e.TargetType, e.TargetID, []byte(e.Changes), e.RequestID)
+	return err
+}
+
+func (p *pgAudit) List(ctx context.Context, aq AuditQuery) ([]models.AuditEntry, string, error) {
+	q, err := newListQuery("-createdAt", "-createdAt", auditSorts, aq.Cursor, aq.Limit)
+	if err != nil {
+		return nil, "", err
+	}
+	q.filter("organization_id = $%d", aq.OrgID)
+	if aq.ActorID != "" {
+		q.filter("actor_id = $%d", aq.ActorID)
+	}
+	if aq.Action != "" {
+		q.filter("action = $%d", aq.Action)
+	}
+	if aq.TargetType != "" {
+		q.filter("target_type = $%d", aq.TargetType)
+	}
+	if aq.TargetID != "" {
+		q.filter("target_id = $%d", aq.TargetID)
+	}
+	if aq.Since != nil {
+		q.filter("created_at >= $%d", *aq.Since)
+	}
+	if aq.Until != nil {
+		q.filter("created_at < $%d", *aq.Until)
+	}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT id, organization_id, actor_type, actor_id, action, target_type, target_id, changes, request_id, created_at
+		FROM audit_log`+q.sql(), q.args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.OrganizationID, &e.ActorType, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.Changes, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	next := q.nextCursor(len(entries), func(i int) (interface{}, string) { return entries[i], entries[i].ID })
+	if next != "" {
+		entries = entries[:q.limit]
+	}
+	return entries, next, nil
+}
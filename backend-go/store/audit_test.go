@@ -0,0 +1,71 @@
+package store
+
+import (
+	"backend-go/db/dbtest"
+	"backend-go/models"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditListPagesMostRecentFirst(t *testing.T) {
+	s := NewPostgres(dbtest.Open(t))
+	ctx := context.Background()
+	orgID := "org_" + uuid.NewString()
+	targetID := uuid.NewString()
+
+	actions := []string{"service.created", "service.updated", "service.updated", "service.deleted", "service.restored"}
+	for _, action := range actions {
+		err := s.Audit.Record(ctx, models.AuditEntry{OrganizationID: orgID, ActorType: "user", ActorID: "user_1",
+			Action: action, TargetType: "service", TargetID: targetID, Changes: []byte(`{}`)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := models.AuditEntry{OrganizationID: "org_" + uuid.NewString(), ActorType: "user", ActorID: "user_1",
+		Action: "service.created", TargetType: "service", TargetID: targetID, Changes: []byte(`{}`)}
+	if err := s.Audit.Record(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	seen := map[string]bool{}
+	q := AuditQuery{OrgID: orgID, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > len(actions) {
+			t.Fatal("paging did not end")
+		}
+		entries, next, err := s.Audit.List(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if seen[e.ID] {
+				t.Fatalf("entry %s listed twice", e.ID)
+			}
+			seen[e.ID] = true
+			got = append(got, e.Action)
+		}
+		if next == "" {
+			break
+		}
+		q.Cursor = next
+	}
+	if len(got) != len(actions) {
+		t.Fatalf("listed %v, want the org's %d entries", got, len(actions))
+	}
+	for i, action := range got {
+		if want := actions[len(actions)-1-i]; action != want {
+			t.Errorf("entry %d is %s, want %s", i, action, want)
+		}
+	}
+
+	updated, _, err := s.Audit.List(ctx, AuditQuery{OrgID: orgID, Action: "service.updated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 2 {
+		t.Errorf("action filter listed %d entries, want 2", len(updated))
+	}
+}
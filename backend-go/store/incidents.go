@@ -0,0 +1,291 @@
+package store
+
+import (
+	"backend-go/models"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type pgIncidents struct {
+	db *sql.DB
+}
+
+const incidentColumns = `id, title, description, type, status, is_resolved, organization_id, created_at, updated_at, version, archived_at`
+
+// incidentColumnsOf qualifies incidentColumns with a table alias.
+func incidentColumnsOf(alias string) string {
+	return alias + "." + strings.ReplaceAll(incidentColumns, ", ", ", "+alias+".")
+}
+
+func scanIncident(row interface{ Scan(...interface{}) error }, i *models.Incident) error {
+	return row.Scan(&i.ID, &i.Title, &i.Description, &i.Type, &i.Status, &i.IsResolved, &i.OrganizationID, &i.CreatedAt, &i.UpdatedAt, &i.Version, &i.ArchivedAt)
+}
+
+func (p *pgIncidents) List(ctx context.Context, iq IncidentQuery) ([]models.Incident, string, error) {
+	q, err := newListQuery(iq.Sort, "-createdAt", incidentSorts, iq.Cursor, iq.Limit)
+	if err != nil {
+		return nil, "", err
+	}
+	q.where = append(q.where, archivedCond(iq.Archived))
+	if iq.OrgID != "" {
+		q.filter("organization_id = $%d", iq.OrgID)
+	}
+	if iq.Type != "" {
+		q.filter("type = $%d", iq.Type)
+	}
+	if iq.Status != "" {
+		q.filter("status = $%d", iq.Status)
+	}
+	if iq.Resolved != nil {
+		q.filter("is_resolved = $%d", *iq.Resolved)
+	}
+	if iq.ServiceID != "" {
+		q.filter("EXISTS (SELECT 1 FROM incident_services isv WHERE isv.incident_id = incidents.id AND isv.service_id::text = $%d)", iq.ServiceID)
+	}
+	if iq.CreatedAfter != nil {
+		q.filter("created_at >= $%d", *iq.CreatedAfter)
+	}
+	if iq.CreatedBefore != nil {
+		q.filter("created_at < $%d", *iq.CreatedBefore)
+	}
+	if iq.Search != "" {
+		q.filter("(title ILIKE $%d OR description ILIKE $%d)", likePattern(iq.Search))
+	}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT `+incidentColumns+` FROM incidents`+q.sql(), q.args...)
+	if err != nil {
+		return nil, "", err
+	}
+	var incidents []models.Incident
+	for rows.Next() {
+		var i models.Incident
+		if err := scanIncident(rows, &i); err != nil {
+			rows.Close()
+			return nil, "", err
+		}
+		incidents = append(incidents, i)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := q.nextCursor(len(incidents), func(i int) (interface{}, string) { return incidents[i], incidents[i].ID })
+	if next != "" {
+		incidents = incidents[:q.limit]
+	}
+	return incidents, next, p.loadRelations(ctx, incidents)
+}
+
+func (p *pgIncidents) Get(ctx context.Context, orgID, id string) (models.Incident, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL`, id, orgID)
+	return p.scanOne(ctx, row)
+}
+
+// scanOne scans a single incident and loads its relations.
+func (p *pgIncidents) scanOne(ctx context.Context, row interface{ Scan(...interface{}) error }) (models.Incident, error) {
+	var i models.Incident
+	if err := scanIncident(row, &i); err != nil {
+		return i, err
+	}
+	incidents := []models.Incident{i}
+	err := p.loadRelations(ctx, incidents)
+	return incidents[0], err
+}
+
+// loadRelations fills in the affected services and timelines of incidents with
+// one query each, however many incidents there are.
+func (p *pgIncidents) loadRelations(ctx context.Context, incidents []models.Incident) error {
+	if len(incidents) == 0 {
+		return nil
+	}
+	ids := make([]string, len(incidents))
+	byID := make(map[string]*models.Incident, len(incidents))
+	for n := range incidents {
+		ids[n] = incidents[n].ID
+		byID[incidents[n].ID] = &incidents[n]
+	}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT isv.incident_id, s.id, s.name, s.status, s.organization_id, s.version FROM services s
+		JOIN incident_services isv ON s.id = isv.service_id WHERE isv.incident_id = ANY($1::uuid[]) AND s.archived_at IS NULL`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			incidentID string
+			s          models.Service
+		)
+		if err := rows.Scan(&incidentID, &s.ID, &s.Name, &s.Status, &s.OrganizationID, &s.Version); err != nil {
+			rows.Close()
+			return err
+		}
+		if i := byID[incidentID]; i != nil {
+			i.Services = append(i.Services, s)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	updates, err := listUpdates(ctx, p.db, ids...)
+	if err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if i := byID[u.IncidentID]; i != nil {
+			i.Updates = append(i.Updates, u)
+		}
+	}
+	return nil
+}
+
+func (p *pgIncidents) Create(ctx context.Context, orgID string, ch IncidentChange) (string, error) {
+	var id string
+	err := inTx(ctx, p.db, func(tx *sql.Tx) error {
+		var err error
+		id, err = insertIncident(ctx, tx, orgID, ch)
+		return err
+	})
+	return id, err
+}
+
+// insertIncident inserts an incident and links its services; q should be a transaction.
+func insertIncident(ctx context.Context, q querier, orgID string, ch IncidentChange) (string, error) {
+	id := uuid.NewString()
+	_, err := q.ExecContext(ctx, `INSERT INTO incidents (id, title, description, type, status, is_resolved, organization_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		id, ch.Title, ch.Description, ch.Type, ch.Status, ch.IsResolved, orgID)
+	if err != nil {
+		return "", err
+	}
+	return id, linkServices(ctx, q, orgID, id, ch.ServiceIDs)
+}
+
+func (p *pgIncidents) Update(ctx context.Context, orgID, id string, ch IncidentChange, version int) (models.Incident, error) {
+	var prev models.Incident
+	err := inTx(ctx, p.db, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL FOR UPDATE`, id, orgID)
+		if err := scanIncident(row, &prev); err != nil {
+			return err
+		}
+		if version != 0 && version != prev.Version {
+			return ErrVersionConflict
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE incidents SET title = $1, description = $2, type = $3, status = $4, is_resolved = $5,
+			version = version + 1, updated_at = now() WHERE id = $6`,
+			ch.Title, ch.Description, ch.Type, ch.Status, ch.IsResolved, id)
+		if err != nil {
+			return err
+		}
+		return relinkServices(ctx, tx, orgID, id, ch.ServiceIDs)
+	})
+	return prev, err
+}
+
+func (p *pgIncidents) Archive(ctx context.Context, orgID, id string) (models.Incident, error) {
+	row := p.db.QueryRowContext(ctx, `UPDATE incidents SET archived_at = now(), version = version + 1, updated_at = now()
+		WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL RETURNING `+incidentColumns, id, orgID)
+	return p.scanOne(ctx, row)
+}
+
+func (p *pgIncidents) Restore(ctx context.Context, orgID, id string) (models.Incident, time.Time, error) {
+	var archivedAt time.Time
+	row := p.db.QueryRowContext(ctx, restoreSQL("incidents", incidentColumns), id, orgID)
+	i, err := p.scanOne(ctx, scanAlso{row, []interface{}{&archivedAt}})
+	return i, archivedAt, err
+}
+
+func (p *pgIncidents) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM incidents WHERE archived_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// linkServices marks the org's active services among serviceIDs as affected by the incident.
+func linkServices(ctx context.Context, q querier, orgID, incidentID string, serviceIDs []string) error {
+	if len(serviceIDs) == 0 {
+		return nil
+	}
+	_, err := q.ExecContext(ctx, `INSERT INTO incident_services (incident_id, service_id)
+		SELECT $1, id FROM services WHERE organization_id = $2 AND archived_at IS NULL AND id::text = ANY($3)
+		ON CONFLICT DO NOTHING`, incidentID, orgID, pq.Array(serviceIDs))
+	return err
+}
+
+// relinkServices makes the org's active services among serviceIDs the ones affected
+// by the incident. Links to archived services are left alone: clients never see
+// them, so they cannot send them back, and restoring a service must bring its
+// incidents back with it.
+func relinkServices(ctx context.Context, q querier, orgID, incidentID string, serviceIDs []string) error {
+	keep := serviceIDs
+	if keep == nil { // pq sends a nil slice as NULL, which would unlink nothing
+		keep = []string{}
+	}
+	_, err := q.ExecContext(ctx, `DELETE FROM incident_services isv USING services s
+		WHERE isv.incident_id = $1 AND s.id = isv.service_id AND s.archived_at IS NULL AND NOT (s.id::text = ANY($2))`,
+		incidentID, pq.Array(keep))
+	if err != nil {
+		return err
+	}
+	return linkServices(ctx, q, orgID, incidentID, serviceIDs)
+}
+
+func (p *pgIncidents) ServiceIDs(ctx context.Context, id string) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT service_id FROM incident_services WHERE incident_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var sid string
+		if err := rows.Scan(&sid); err != nil {
+			return nil, err
+		}
+		ids = append(ids, sid)
+	}
+	return ids, rows.Err()
+}
+
+type pgUpdates struct {
+	db *sql.DB
+}
+
+func (p *pgUpdates) Add(ctx context.Context, orgID, incidentID, message string) (models.IncidentUpdate, error) {
+	u := models.IncidentUpdate{ID: uuid.NewString(), IncidentID: incidentID, Message: message}
+	err := p.db.QueryRowContext(ctx, `INSERT INTO incident_updates (id, incident_id, message)
+		SELECT $1, id, $2 FROM incidents WHERE id = $3 AND organization_id = $4 AND archived_at IS NULL RETURNING created_at`,
+		u.ID, message, incidentID, orgID).Scan(&u.CreatedAt)
+	return u, err
+}
+
+func (p *pgUpdates) List(ctx context.Context, incidentID string) ([]models.IncidentUpdate, error) {
+	return listUpdates(ctx, p.db, incidentID)
+}
+
+// listUpdates returns the updates of the given incidents, oldest first.
+func listUpdates(ctx context.Context, q querier, incidentIDs ...string) ([]models.IncidentUpdate, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, incident_id, message, created_at FROM incident_updates
+		WHERE incident_id = ANY($1::uuid[]) ORDER BY created_at ASC, id`, pq.Array(incidentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var updates []models.IncidentUpdate
+	for rows.Next() {
+		var u models.IncidentUpdate
+		if err := rows.Scan(&u.ID, &u.IncidentID, &u.Message, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+	return updates, rows.Err()
+}
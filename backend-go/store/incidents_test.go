@@ -0,0 +1,133 @@
+package store
+
+import (
+	"backend-go/db/dbtest"
+	"backend-go/models"
+	"context"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestIncidentListLoadsRelationsInBatches(t *testing.T) {
+	s := NewPostgres(dbtest.Open(t))
+	ctx := context.Background()
+	orgID := "org_" + uuid.NewString()
+
+	api := models.Service{ID: uuid.NewString(), Name: "API", Status: "Major Outage", OrganizationID: orgID}
+	web := models.Service{ID: uuid.NewString(), Name: "Web", Status: "Operational", OrganizationID: orgID}
+	for _, svc := range []models.Service{api, web} {
+		if err := s.Services.Create(ctx, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create := func(title string, serviceIDs ...string) string {
+		t.Helper()
+		id, err := s.Incidents.Create(ctx, orgID, IncidentChange{Title: title, Type: "incident", Status: "Investigating", ServiceIDs: serviceIDs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	both := create("Everything down", api.ID, web.ID)
+	webOnly := create("Web slow", web.ID)
+	bare := create("Unrelated")
+	for _, msg := range []string{"Investigating", "Rolled back"} {
+		if _, err := s.Updates.Add(ctx, orgID, both, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Updates.Add(ctx, orgID, webOnly, "Scaling up"); err != nil {
+		t.Fatal(err)
+	}
+
+	list, _, err := s.Incidents.List(ctx, IncidentQuery{OrgID: orgID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("listed %d incidents, want 3", len(list))
+	}
+	want := map[string]struct {
+		services []string
+		updates  []string
+	}{
+		both:    {[]string{"API", "Web"}, []string{"Investigating", "Rolled back"}},
+		webOnly: {[]string{"Web"}, []string{"Scaling up"}},
+		bare:    {nil, nil},
+	}
+	for _, i := range list {
+		w, ok := want[i.ID]
+		if !ok {
+			t.Fatalf("listed unexpected incident %s", i.ID)
+		}
+		var services, updates []string
+		for _, svc := range i.Services {
+			services = append(services, svc.Name)
+		}
+		sort.Strings(services)
+		for _, u := range i.Updates {
+			if u.IncidentID != i.ID {
+				t.Errorf("%s: update %s belongs to %s", i.Title, u.ID, u.IncidentID)
+			}
+			updates = append(updates, u.Message)
+		}
+		if !slices.Equal(services, w.services) || !slices.Equal(updates, w.updates) {
+			t.Errorf("%s: services %v and updates %v, want %v and %v", i.Title, services, updates, w.services, w.updates)
+		}
+	}
+
+	got, err := s.Incidents.Get(ctx, orgID, bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Services) != 0 || len(got.Updates) != 0 {
+		t.Errorf("incident without relations got services %v and updates %v", got.Services, got.Updates)
+	}
+}
+
+func TestIncidentUpdateKeepsArchivedServiceLinks(t *testing.T) {
+	s := NewPostgres(dbtest.Open(t))
+	ctx := context.Background()
+	orgID := "org_" + uuid.NewString()
+
+	archived := models.Service{ID: uuid.NewString(), Name: "API", Status: "Major Outage", OrganizationID: orgID}
+	active := models.Service{ID: uuid.NewString(), Name: "Web", Status: "Operational", OrganizationID: orgID}
+	for _, svc := range []models.Service{archived, active} {
+		if err := s.Services.Create(ctx, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ch := IncidentChange{Title: "API down", Type: "incident", Status: "Investigating", ServiceIDs: []string{archived.ID, active.ID}}
+	id, err := s.Incidents.Create(ctx, orgID, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone, err := s.Services.Archive(ctx, orgID, archived.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a client only sees the active service, so that is all it sends back
+	ch.Status, ch.ServiceIDs = "Identified", nil
+	if _, err := s.Incidents.Update(ctx, orgID, id, ch, 0); err != nil {
+		t.Fatal(err)
+	}
+	restored, archivedAt, err := s.Services.Restore(ctx, orgID, archived.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ArchivedAt != nil || !archivedAt.Equal(*gone.ArchivedAt) {
+		t.Errorf("restore returned archivedAt %v and %v, want nil and %v", restored.ArchivedAt, archivedAt, gone.ArchivedAt)
+	}
+
+	i, err := s.Incidents.Get(ctx, orgID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(i.Services) != 1 || i.Services[0].ID != archived.ID {
+		t.Errorf("services = %+v, want only the restored service", i.Services)
+	}
+}
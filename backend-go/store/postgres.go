@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// NewPostgres returns Postgres-backed stores using db.
+func NewPostgres(db *sql.DB) Stores {
+	return Stores{
+		Services:    &pgServices{db: db},
+		History:     &pgHistory{db: db},
+		Incidents:   &pgIncidents{db: db},
+		Updates:     &pgUpdates{db: db},
+		Audit:       &pgAudit{db: db},
+		AlertGroups: &pgAlertGroups{db: db},
+	}
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// scanAlso scans the columns after a model's own ones into extra.
+type scanAlso struct {
+	row   *sql.Row
+	extra []interface{}
+}
+
+func (s scanAlso) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// restoreSQL returns the UPDATE that restores an archived row of table,
+// returning columns followed by the time the row had been archived.
+func restoreSQL(table, columns string) string {
+	return `WITH old AS (SELECT archived_at FROM ` + table + ` WHERE id = $1 AND organization_id = $2 AND archived_at IS NOT NULL FOR UPDATE)
+		UPDATE ` + table + ` SET archived_at = NULL, version = version + 1, updated_at = now()
+		WHERE id = $1 AND organization_id = $2 AND archived_at IS NOT NULL RETURNING ` + columns + `, (SELECT archived_at FROM old)`
+}
+
+// inTx runs fn in a transaction, committing if it returns nil.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"backend-go/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// QueryError reports an invalid filter, sort or cursor in a listing request.
+type QueryError struct {
+	Msg string
+}
+
+func (e *QueryError) Error() string { return e.Msg }
+
+// ServiceQuery selects a page of services. An empty OrgID lists every organization.
+type ServiceQuery struct {
+	OrgID  string
+	Status string
+	// list archived services instead of active ones
+	Archived bool
+	// matched case-insensitively against the name
+	Search string
+	// "name" or "status", prefixed with "-" for descending; default "name"
+	Sort   string
+	Cursor string
+	Limit  int
+}
+
+// IncidentQuery selects a page of incidents. An empty OrgID lists every organization.
+type IncidentQuery struct {
+	OrgID         string
+	Type          string
+	Status        string
+	Resolved      *bool
+	ServiceID     string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// list archived incidents instead of active ones
+	Archived bool
+	// matched case-insensitively against the title and description
+	Search string
+	// "createdAt", "updatedAt" or "title", prefixed with "-" for descending; default "-createdAt"
+	Sort   string
+	Cursor string
+	Limit  int
+}
+
+// AuditQuery selects a page of an organization's audit log, most recent first.
+type AuditQuery struct {
+	OrgID      string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Cursor     string
+	Limit      int
+}
+
+// sortColumn is a sortable column; value extracts the cursor value from a row.
+type sortColumn struct {
+	column string
+	cast   string
+	value  func(row interface{}) string
+}
+
+var serviceSorts = map[string]sortColumn{
+	"name":   {"name", "text", func(r interface{}) string { return r.(models.Service).Name }},
+	"status": {"status", "text", func(r interface{}) string { return r.(models.Service).Status }},
+}
+
+var incidentSorts = map[string]sortColumn{
+	"createdAt": {"created_at", "timestamptz", func(r interface{}) string { return r.(models.Incident).CreatedAt.Format(time.RFC3339Nano) }},
+	"updatedAt": {"updated_at", "timestamptz", func(r interface{}) string { return r.(models.Incident).UpdatedAt.Format(time.RFC3339Nano) }},
+	"title":     {"title", "text", func(r interface{}) string { return r.(models.Incident).Title }},
+}
+
+var auditSorts = map[string]sortColumn{
+	"createdAt": {"created_at", "timestamptz", func(r interface{}) string { return r.(models.AuditEntry).CreatedAt.Format(time.RFC3339Nano) }},
+}
+
+// cursor is the position after the last row of a page, encoded opaquely for clients.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func (c cursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a cursor issued for a listing sorted by col, checking its
+// value and ID so a tampered or stale cursor is reported instead of failing the query.
+func decodeCursor(s string, col sortColumn) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(raw, &c)
+	}
+	if err != nil || uuid.Validate(c.ID) != nil {
+		return c, &QueryError{"Invalid cursor"}
+	}
+	if col.cast == "timestamptz" {
+		if _, err := time.Parse(time.RFC3339Nano, c.Value); err != nil {
+			return c, &QueryError{"Invalid cursor"}
+		}
+	}
+	return c, nil
+}
+
+// listQuery builds the WHERE, ORDER BY and LIMIT of a keyset-paginated listing.
+type listQuery struct {
+	where []string
+	args  []interface{}
+	sort  string
+	col   sortColumn
+	desc  bool
+	limit int
+}
+
+func newListQuery(sort, defaultSort string, sorts map[string]sortColumn, cur string, limit int) (*listQuery, error) {
+	if sort == "" {
+		sort = defaultSort
+	}
+	q := &listQuery{sort: sort, desc: strings.HasPrefix(sort, "-"), limit: limit}
+	col, ok := sorts[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return nil, &QueryError{"Unsupported sort " + sort}
+	}
+	q.col = col
+
+	switch {
+	case q.limit == 0:
+		q.limit = DefaultPageSize
+	case q.limit < 1 || q.limit > MaxPageSize:
+		return nil, &QueryError{fmt.Sprintf("limit must be between 1 and %d", MaxPageSize)}
+	}
+
+	if cur != "" {
+		c, err := decodeCursor(cur, col)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != sort {
+			return nil, &QueryError{"Cursor was issued for a different sort"}
+		}
+		op := ">"
+		if q.desc {
+			op = "<"
+		}
+		q.args = append(q.args, c.Value, c.ID)
+		q.where = append(q.where, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d::uuid)", col.column, op, len(q.args)-1, col.cast, len(q.args)))
+	}
+	return q, nil
+}
+
+// filter adds a condition; each %d in cond is replaced by the placeholder for v.
+func (q *listQuery) filter(cond string, v interface{}) {
+	q.args = append(q.args, v)
+	n := len(q.args)
+	q.where = append(q.where, strings.ReplaceAll(cond, "%d", fmt.Sprint(n)))
+}
+
+// sql returns the clauses that follow FROM, fetching one extra row to detect a next page.
+func (q *listQuery) sql() string {
+	var b strings.Builder
+	if len(q.where) > 0 {
+		b.WriteString(" WHERE " + strings.Join(q.where, " AND "))
+	}
+	dir := "ASC"
+	if q.desc {
+		dir = "DESC"
+	}
+	fmt.Fprintf(&b, " ORDER BY %s %s, id %s LIMIT %d", q.col.column, dir, dir, q.limit+1)
+	return b.String()
+}
+
+// nextCursor returns the cursor for the page after the first q.limit of n fetched
+// rows, or "" on the last page. row returns the i-th row and its ID.
+func (q *listQuery) nextCursor(n int, row func(i int) (interface{}, string)) string {
+	if n <= q.limit {
+		return ""
+	}
+	r, id := row(q.limit - 1)
+	return cursor{Sort: q.sort, Value: q.col.value(r), ID: id}.encode()
+}
+
+// archivedCond selects archived rows, or active ones when archived is false.
+func archivedCond(archived bool) string {
+	if archived {
+		return "archived_at IS NOT NULL"
+	}
+	return "archived_at IS NULL"
+}
+
+// likePattern matches s anywhere, treating LIKE wildcards in s literally.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
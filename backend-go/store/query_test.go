@@ -0,0 +1,140 @@
+package store
+
+import (
+	"backend-go/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testID = "7b0f6a0e-4a55-4a8e-9f43-3c1e6f1b8d2a"
+
+func TestCursorRoundTrip(t *testing.T) {
+	cases := []struct {
+		col    sortColumn
+		cursor cursor
+	}{
+		{serviceSorts["name"], cursor{Sort: "name", Value: "API", ID: testID}},
+		{incidentSorts["createdAt"], cursor{Sort: "-createdAt", Value: "2026-01-02T03:04:05.123456Z", ID: testID}},
+		{incidentSorts["title"], cursor{Sort: "title", Value: `quotes " and unicode ✓`, ID: testID}},
+	}
+	for _, tc := range cases {
+		want := tc.cursor
+		encoded := want.encode()
+		if strings.ContainsAny(encoded, "+/=") {
+			t.Errorf("cursor %q is not URL-safe", encoded)
+		}
+		got, err := decodeCursor(encoded, tc.col)
+		if err != nil {
+			t.Fatalf("decode %q: %v", encoded, err)
+		}
+		if got != want {
+			t.Errorf("round trip = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalid(t *testing.T) {
+	createdAt := incidentSorts["createdAt"]
+	cases := []struct {
+		name string
+		s    string
+		col  sortColumn
+	}{
+		{"empty", "", createdAt},
+		{"not base64", "not base64!", createdAt},
+		{"not JSON", "bm90IGpzb24", createdAt},
+		{"no ID", cursor{Sort: "name", Value: "API"}.encode(), serviceSorts["name"]},
+		{"ID not a UUID", cursor{Sort: "name", Value: "API", ID: "1 OR 1=1"}.encode(), serviceSorts["name"]},
+		{"time not a timestamp", cursor{Sort: "-createdAt", Value: "yesterday", ID: testID}.encode(), createdAt},
+		{"time without zone", cursor{Sort: "-createdAt", Value: "2026-01-02 03:04:05", ID: testID}.encode(), createdAt},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeCursor(tc.s, tc.col)
+			var qerr *QueryError
+			if !errors.As(err, &qerr) {
+				t.Fatalf("err = %v, want a *QueryError", err)
+			}
+		})
+	}
+}
+
+func TestNewListQuery(t *testing.T) {
+	cur := cursor{Sort: "-createdAt", Value: "2026-01-02T03:04:05Z", ID: testID}.encode()
+	q, err := newListQuery("", "-createdAt", incidentSorts, cur, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.limit != DefaultPageSize {
+		t.Errorf("limit = %d, want %d", q.limit, DefaultPageSize)
+	}
+	want := " WHERE (created_at, id) < ($1::timestamptz, $2::uuid) ORDER BY created_at DESC, id DESC LIMIT 51"
+	if got := q.sql(); got != want {
+		t.Errorf("sql = %q\nwant  %q", got, want)
+	}
+
+	q, err = newListQuery("name", "name", serviceSorts, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.filter("organization_id = $%d", "org_1")
+	q.filter("(name ILIKE $%d OR status ILIKE $%d)", "%api%")
+	want = " WHERE organization_id = $1 AND (name ILIKE $2 OR status ILIKE $2) ORDER BY name ASC, id ASC LIMIT 11"
+	if got := q.sql(); got != want {
+		t.Errorf("sql = %q\nwant  %q", got, want)
+	}
+}
+
+func TestNewListQueryErrors(t *testing.T) {
+	nameCursor := cursor{Sort: "name", Value: "API", ID: testID}.encode()
+	cases := []struct {
+		name   string
+		sort   string
+		cursor string
+		limit  int
+	}{
+		{"unknown sort", "uptime", "", 0},
+		{"limit too large", "name", "", MaxPageSize + 1},
+		{"negative limit", "name", "", -1},
+		{"garbage cursor", "name", "%%%", 0},
+		{"cursor for another sort", "-name", nameCursor, 0},
+		{"tampered cursor", "name", cursor{Sort: "name", Value: "API", ID: "x"}.encode(), 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newListQuery(tc.sort, "name", serviceSorts, tc.cursor, tc.limit)
+			var qerr *QueryError
+			if !errors.As(err, &qerr) {
+				t.Fatalf("err = %v, want a *QueryError", err)
+			}
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []models.Incident{
+		{ID: "0d3c7a52-3b0e-4c6f-8f51-6d0b0b5e2a01", CreatedAt: created.Add(2 * time.Minute)},
+		{ID: testID, CreatedAt: created.Add(time.Minute)},
+		{ID: "0d3c7a52-3b0e-4c6f-8f51-6d0b0b5e2a03", CreatedAt: created},
+	}
+	q, err := newListQuery("-createdAt", "-createdAt", incidentSorts, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := func(i int) (interface{}, string) { return rows[i], rows[i].ID }
+
+	if next := q.nextCursor(2, row); next != "" {
+		t.Errorf("a full last page must not have a next cursor, got %q", next)
+	}
+	next, err := decodeCursor(q.nextCursor(3, row), q.col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cursor{Sort: "-createdAt", Value: rows[1].CreatedAt.Format(time.RFC3339Nano), ID: testID}
+	if next != want {
+		t.Errorf("next cursor = %+v, want %+v", next, want)
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"backend-go/models"
+	"context"
+)
+
+// headlineOptions are the ts_headline options for result snippets.
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+// escapeHTML wraps a SQL text expression so it is HTML-escaped. Search snippets
+// escape their source text before ts_headline adds <mark> tags, so those tags
+// are the only markup in them.
+func escapeHTML(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// searchQuery ranks an org's incidents by their own match plus that of their
+// updates, then builds snippets for the top results only.
+var searchQuery = `
+WITH q AS (SELECT websearch_to_tsquery('english', $2) AS query),
+matches AS (
+	SELECT i.id AS incident_id, ts_rank(i.search_vector, q.query) AS rank
+	FROM incidents i, q
+	WHERE i.organization_id = $1 AND i.archived_at IS NULL AND i.search_vector @@ q.query
+	UNION ALL
+	SELECT u.incident_id, ts_rank(u.search_vector, q.query)
+	FROM incident_updates u JOIN incidents i ON i.id = u.incident_id, q
+	WHERE i.organization_id = $1 AND i.archived_at IS NULL AND u.search_vector @@ q.query
+),
+best AS (
+	SELECT incident_id, sum(rank) AS rank FROM matches
+	GROUP BY incident_id ORDER BY rank DESC LIMIT $3
+)
+SELECT ` + incidentColumnsOf("i") + `, b.rank,
+	ts_headline('english', ` + escapeHTML("i.title") + `, q.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+	ts_headline('english', ` + escapeHTML("coalesce(i.description, '')") + `, q.query, '` + headlineOptions + `'),
+	u.id, ts_headline('english', ` + escapeHTML("u.message") + `, q.query, '` + headlineOptions + `')
+FROM best b
+JOIN incidents i ON i.id = b.incident_id
+CROSS JOIN q
+LEFT JOIN LATERAL (
+	SELECT id, message FROM incident_updates
+	WHERE incident_id = i.id AND search_vector @@ q.query
+	ORDER BY ts_rank(search_vector, q.query) DESC, created_at DESC LIMIT 1
+) u ON true
+ORDER BY b.rank DESC, i.created_at DESC`
+
+func (p *pgIncidents) Search(ctx context.Context, orgID, query string, limit int) ([]models.IncidentSearchResult, error) {
+	rows, err := p.db.QueryContext(ctx, searchQuery, orgID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	var results []models.IncidentSearchResult
+	for rows.Next() {
+		var (
+			r models.IncidentSearchResult
+			i = &r.Incident
+		)
+		err := rows.Scan(&i.ID, &i.Title, &i.Description, &i.Type, &i.Status, &i.IsResolved, &i.OrganizationID, &i.CreatedAt, &i.UpdatedAt, &i.Version, &i.ArchivedAt,
+			&r.Rank, &r.Highlights.Title, &r.Highlights.Description, &r.Highlights.UpdateID, &r.Highlights.Update)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	incidents := make([]models.Incident, len(results))
+	for n := range results {
+		incidents[n] = results[n].Incident
+	}
+	if err := p.loadRelations(ctx, incidents); err != nil {
+		return nil, err
+	}
+	for n := range results {
+		results[n].Incident = incidents[n]
+	}
+	return results, nil
+}
@@ -0,0 +1,20 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+// Snippets are inserted as HTML by clients, so every ts_headline source must be escaped.
+func TestSearchQueryEscapesHighlightSources(t *testing.T) {
+	headlines := strings.Count(searchQuery, "ts_headline(")
+	escaped := 0
+	for _, src := range []string{"i.title", "coalesce(i.description, '')", "u.message"} {
+		if strings.Contains(searchQuery, "ts_headline('english', "+escapeHTML(src)+",") {
+			escaped++
+		}
+	}
+	if headlines == 0 || escaped != headlines {
+		t.Fatalf("%d of %d ts_headline calls escape their source", escaped, headlines)
+	}
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"backend-go/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type pgServices struct {
+	db *sql.DB
+}
+
+const serviceColumns = `id, name, status, organization_id, version, archived_at`
+
+func scanService(row interface{ Scan(...interface{}) error }, s *models.Service) error {
+	return row.Scan(&s.ID, &s.Name, &s.Status, &s.OrganizationID, &s.Version, &s.ArchivedAt)
+}
+
+func scanServices(rows *sql.Rows) ([]models.Service, error) {
+	defer rows.Close()
+	var services []models.Service
+	for rows.Next() {
+		var s models.Service
+		if err := scanService(rows, &s); err != nil {
+			return nil, err
+		}
+		services = append(services, s)
+	}
+	return services, rows.Err()
+}
+
+func (p *pgServices) List(ctx context.Context, sq ServiceQuery) ([]models.Service, string, error) {
+	q, err := newListQuery(sq.Sort, "name", serviceSorts, sq.Cursor, sq.Limit)
+	if err != nil {
+		return nil, "", err
+	}
+	q.where = append(q.where, archivedCond(sq.Archived))
+	if sq.OrgID != "" {
+		q.filter("organization_id = $%d", sq.OrgID)
+	}
+	if sq.Status != "" {
+		q.filter("status = $%d", sq.Status)
+	}
+	if sq.Search != "" {
+		q.filter("name ILIKE $%d", likePattern(sq.Search))
+	}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT `+serviceColumns+` FROM services`+q.sql(), q.args...)
+	if err != nil {
+		return nil, "", err
+	}
+	services, err := scanServices(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	next := q.nextCursor(len(services), func(i int) (interface{}, string) { return services[i], services[i].ID })
+	if next != "" {
+		services = services[:q.limit]
+	}
+	return services, next, nil
+}
+
+func (p *pgServices) Get(ctx context.Context, orgID, id string) (models.Service, error) {
+	var s models.Service
+	row := p.db.QueryRowContext(ctx, `SELECT `+serviceColumns+` FROM services WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL`, id, orgID)
+	err := scanService(row, &s)
+	return s, err
+}
+
+func (p *pgServices) Create(ctx context.Context, s models.Service) error {
+	return inTx(ctx, p.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO services (id, name, status, organization_id) VALUES ($1, $2, $3, $4)`,
+			s.ID, s.Name, s.Status, s.OrganizationID)
+		if err != nil {
+			return err
+		}
+		return recordStatus(ctx, tx, s.ID, s.Status)
+	})
+}
+
+func (p *pgServices) Update(ctx context.Context, orgID, id, name, status string, version int) (models.Service, error) {
+	prev := models.Service{ID: id, OrganizationID: orgID}
+	err := inTx(ctx, p.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT name, status, version FROM services WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL FOR UPDATE`, id, orgID).
+			Scan(&prev.Name, &prev.Status, &prev.Version)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != prev.Version {
+			return ErrVersionConflict
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE services SET name = $1, status = $2, version = version + 1, updated_at = now() WHERE id = $3`, name, status, id)
+		if err != nil {
+			return err
+		}
+		if prev.Status == status {
+			return nil
+		}
+		return recordStatus(ctx, tx, id, status)
+	})
+	return prev, err
+}
+
+func (p *pgServices) Unknown(ctx context.Context, orgID string, ids []string) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT ref FROM unnest($2::text[]) AS ref
+		WHERE NOT EXISTS (SELECT 1 FROM services WHERE id::text = ref AND organization_id = $1 AND archived_at IS NULL)`,
+		orgID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var unknown []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		unknown = append(unknown, id)
+	}
+	return unknown, rows.Err()
+}
+
+func (p *pgServices) Archive(ctx context.Context, orgID, id string) (models.Service, error) {
+	var s models.Service
+	row := p.db.QueryRowContext(ctx, `UPDATE services SET archived_at = now(), version = version + 1, updated_at = now()
+		WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL RETURNING `+serviceColumns, id, orgID)
+	err := scanService(row, &s)
+	return s, err
+}
+
+func (p *pgServices) Restore(ctx context.Context, orgID, id string) (models.Service, time.Time, error) {
+	var s models.Service
+	var archivedAt time.Time
+	row := p.db.QueryRowContext(ctx, restoreSQL("services", serviceColumns), id, orgID)
+	err := scanService(scanAlso{row, []interface{}{&archivedAt}}, &s)
+	return s, archivedAt, err
+}
+
+func (p *pgServices) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM services WHERE archived_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func recordStatus(ctx context.Context, q querier, serviceID, status string) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO service_status_history (id, service_id, status) VALUES ($1, $2, $3)`,
+		uuid.NewString(), serviceID, status)
+	return err
+}
+
+type pgHistory struct {
+	db *sql.DB
+}
+
+func (p *pgHistory) Since(ctx context.Context, serviceID string, since time.Time) ([]models.StatusChange, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT status, changed_at FROM service_status_history
+		WHERE service_id = $1 AND changed_at >= $2 ORDER BY changed_at ASC`, serviceID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []models.StatusChange
+	for rows.Next() {
+		var h models.StatusChange
+		if err := rows.Scan(&h.Status, &h.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
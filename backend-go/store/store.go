@@ -0,0 +1,127 @@
+// Package store provides data access for services, incidents and the audit log.
+// Handlers depend on the interfaces so they can be exercised with fakes; the
+// Postgres implementations run each logical write in a single transaction.
+//
+// Lookups of a single row return sql.ErrNoRows when it does not exist or belongs
+// to another organization.
+//
+// Services and incidents carry a version that every update increments. Updates
+// take the version the caller last read, or 0 to skip the check, and fail with
+// ErrVersionConflict when the row has changed since.
+//
+// Deleting a service or incident archives it. Archived rows are hidden from Get,
+// Update and Search and only listed when a query asks for them; Restore brings
+// them back and Purge removes them for good once their retention has passed.
+package store
+
+import (
+	"backend-go/models"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrVersionConflict reports an update based on a stale version of a row.
+var ErrVersionConflict = errors.New("store: version conflict")
+
+type ServiceStore interface {
+	// List returns a page of services and the cursor of the next page, "" on the last.
+	// Invalid filters, sorts and cursors return a *QueryError.
+	List(ctx context.Context, q ServiceQuery) ([]models.Service, string, error)
+	// Get returns an active service.
+	Get(ctx context.Context, orgID, id string) (models.Service, error)
+	// Create inserts s and its initial status history entry.
+	Create(ctx context.Context, s models.Service) error
+	// Update renames a service and sets its status, recording a history entry
+	// when the status changes. It returns the service as it was before.
+	Update(ctx context.Context, orgID, id, name, status string, version int) (models.Service, error)
+	// Unknown returns the IDs among ids that are not active services of the org.
+	Unknown(ctx context.Context, orgID string, ids []string) ([]string, error)
+	// Archive hides an active service and returns it; its status history is kept.
+	Archive(ctx context.Context, orgID, id string) (models.Service, error)
+	// Restore returns an archived service to the active ones, returning it and
+	// when it had been archived.
+	Restore(ctx context.Context, orgID, id string) (models.Service, time.Time, error)
+	// Purge deletes services archived before cutoff, with their history, and
+	// returns how many there were.
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type HistoryStore interface {
+	// Since returns a service's status changes after since, oldest first.
+	Since(ctx context.Context, serviceID string, since time.Time) ([]models.StatusChange, error)
+}
+
+// IncidentChange is the writable part of an incident.
+type IncidentChange struct {
+	Title       string
+	Description string
+	Type        string
+	Status      string
+	IsResolved  bool
+	ServiceIDs  []string
+}
+
+type IncidentStore interface {
+	// List returns a page of incidents with services and updates and the cursor of
+	// the next page, "" on the last. Invalid filters, sorts and cursors return a *QueryError.
+	List(ctx context.Context, q IncidentQuery) ([]models.Incident, string, error)
+	// Get returns an active incident with its active services and updates.
+	Get(ctx context.Context, orgID, id string) (models.Incident, error)
+	// Create inserts an incident and links the org's services among ch.ServiceIDs.
+	Create(ctx context.Context, orgID string, ch IncidentChange) (string, error)
+	// Update replaces an incident's fields and active affected services, keeping
+	// its links to archived ones. It returns the incident as it was before,
+	// without services or updates.
+	Update(ctx context.Context, orgID, id string, ch IncidentChange, version int) (models.Incident, error)
+	// Search ranks an organization's incidents by how well their title, description
+	// and updates match a web-style query ("redis outage", "-maintenance", "\"exact phrase\"").
+	Search(ctx context.Context, orgID, query string, limit int) ([]models.IncidentSearchResult, error)
+	// Archive hides an active incident and returns it; its timeline is kept.
+	Archive(ctx context.Context, orgID, id string) (models.Incident, error)
+	// Restore returns an archived incident to the active ones, returning it and
+	// when it had been archived.
+	Restore(ctx context.Context, orgID, id string) (models.Incident, time.Time, error)
+	// Purge deletes incidents archived before cutoff, with their timelines, and
+	// returns how many there were.
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+	// ServiceIDs returns the IDs of the services an incident affects.
+	ServiceIDs(ctx context.Context, id string) ([]string, error)
+}
+
+type UpdateStore interface {
+	// Add posts a message to the timeline of an active org-owned incident.
+	Add(ctx context.Context, orgID, incidentID, message string) (models.IncidentUpdate, error)
+	// List returns an incident's updates, oldest first.
+	List(ctx context.Context, incidentID string) ([]models.IncidentUpdate, error)
+}
+
+type AuditStore interface {
+	// Record appends an entry to the audit log; ID and CreatedAt are assigned.
+	Record(ctx context.Context, e models.AuditEntry) error
+	// List returns a page of entries and the cursor of the next page, "" on the last.
+	// Invalid filters and cursors return a *QueryError.
+	List(ctx context.Context, q AuditQuery) ([]models.AuditEntry, string, error)
+}
+
+// AlertGroupStore tracks the incident opened for each Alertmanager alert group.
+type AlertGroupStore interface {
+	// Incident returns the ID of the incident recorded for a group, "" when there is none.
+	Incident(ctx context.Context, receiverID, groupKey string) (string, error)
+	// Open claims a group and inserts an incident for it in one transaction, unless
+	// the group already has an open incident. It returns the group's incident and
+	// whether it was created, so concurrent notifications open only one.
+	Open(ctx context.Context, orgID, receiverID, groupKey string, ch IncidentChange) (string, bool, error)
+	// Release forgets a group whose incident, incidentID, was resolved.
+	Release(ctx context.Context, receiverID, groupKey, incidentID string) error
+}
+
+// Stores bundles the stores the HTTP handlers use.
+type Stores struct {
+	Services    ServiceStore
+	History     HistoryStore
+	Incidents   IncidentStore
+	Updates     UpdateStore
+	Audit       AuditStore
+	AlertGroups AlertGroupStore
+}
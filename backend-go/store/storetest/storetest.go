@@ -0,0 +1,307 @@
+// Package storetest provides in-memory stores for handler tests. Each fake
+// embeds the interface it implements, so calling a method a test has not
+// needed yet panics instead of silently returning nothing.
+package storetest
+
+import (
+	"backend-go/models"
+	"backend-go/store"
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Memory holds the rows behind the fake stores. Tests may read and change them
+// between requests.
+type Memory struct {
+	lock      sync.Mutex
+	Services  map[string]*models.Service
+	Incidents map[string]*models.Incident
+	Links     map[string][]string // incident ID to service IDs
+	Updates   []models.IncidentUpdate
+	Audit     []models.AuditEntry
+}
+
+func New() *Memory {
+	return &Memory{
+		Services:  map[string]*models.Service{},
+		Incidents: map[string]*models.Incident{},
+		Links:     map[string][]string{},
+	}
+}
+
+// Stores returns fakes backed by m.
+func (m *Memory) Stores() store.Stores {
+	return store.Stores{
+		Services:  services{m: m},
+		Incidents: incidents{m: m},
+		Updates:   updates{m: m},
+		Audit:     audit{m: m},
+	}
+}
+
+// AuditActions returns the audited actions in order.
+func (m *Memory) AuditActions() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var out []string
+	for _, e := range m.Audit {
+		out = append(out, e.Action)
+	}
+	return out
+}
+
+// service returns the org's active service with id, or nil.
+func (m *Memory) service(orgID, id string) *models.Service {
+	s := m.Services[id]
+	if s == nil || s.OrganizationID != orgID || s.ArchivedAt != nil {
+		return nil
+	}
+	return s
+}
+
+// incident returns the org's active incident with id, or nil.
+func (m *Memory) incident(orgID, id string) *models.Incident {
+	i := m.Incidents[id]
+	if i == nil || i.OrganizationID != orgID || i.ArchivedAt != nil {
+		return nil
+	}
+	return i
+}
+
+// load returns a copy of i with its active services and updates.
+func (m *Memory) load(i *models.Incident) models.Incident {
+	out := *i
+	out.Services, out.Updates = nil, nil
+	for _, id := range m.Links[i.ID] {
+		if s := m.service(i.OrganizationID, id); s != nil {
+			out.Services = append(out.Services, *s)
+		}
+	}
+	for _, u := range m.Updates {
+		if u.IncidentID == i.ID {
+			out.Updates = append(out.Updates, u)
+		}
+	}
+	return out
+}
+
+// link keeps the org's active services among ids, as the Postgres store does.
+func (m *Memory) link(orgID string, ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		if m.service(orgID, id) != nil {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+type services struct {
+	store.ServiceStore
+	m *Memory
+}
+
+func (s services) Get(ctx context.Context, orgID, id string) (models.Service, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	svc := s.m.service(orgID, id)
+	if svc == nil {
+		return models.Service{}, sql.ErrNoRows
+	}
+	return *svc, nil
+}
+
+func (s services) Create(ctx context.Context, svc models.Service) error {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	if svc.Version == 0 {
+		svc.Version = 1
+	}
+	s.m.Services[svc.ID] = &svc
+	return nil
+}
+
+func (s services) Update(ctx context.Context, orgID, id, name, status string, version int) (models.Service, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	svc := s.m.service(orgID, id)
+	if svc == nil {
+		return models.Service{}, sql.ErrNoRows
+	}
+	if version != 0 && version != svc.Version {
+		return models.Service{}, store.ErrVersionConflict
+	}
+	prev := *svc
+	svc.Name, svc.Status = name, status
+	svc.Version++
+	return prev, nil
+}
+
+func (s services) Unknown(ctx context.Context, orgID string, ids []string) ([]string, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	var unknown []string
+	for _, id := range ids {
+		if s.m.service(orgID, id) == nil {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown, nil
+}
+
+func (s services) Archive(ctx context.Context, orgID, id string) (models.Service, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	svc := s.m.service(orgID, id)
+	if svc == nil {
+		return models.Service{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	svc.ArchivedAt = &now
+	svc.Version++
+	return *svc, nil
+}
+
+func (s services) Restore(ctx context.Context, orgID, id string) (models.Service, time.Time, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	svc := s.m.Services[id]
+	if svc == nil || svc.OrganizationID != orgID || svc.ArchivedAt == nil {
+		return models.Service{}, time.Time{}, sql.ErrNoRows
+	}
+	archivedAt := *svc.ArchivedAt
+	svc.ArchivedAt = nil
+	svc.Version++
+	return *svc, archivedAt, nil
+}
+
+type incidents struct {
+	store.IncidentStore
+	m *Memory
+}
+
+func (s incidents) Get(ctx context.Context, orgID, id string) (models.Incident, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	i := s.m.incident(orgID, id)
+	if i == nil {
+		return models.Incident{}, sql.ErrNoRows
+	}
+	return s.m.load(i), nil
+}
+
+func (s incidents) Create(ctx context.Context, orgID string, ch store.IncidentChange) (string, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	now := time.Now()
+	i := &models.Incident{
+		ID: uuid.NewString(), Title: ch.Title, Description: ch.Description, Type: ch.Type,
+		Status: ch.Status, IsResolved: ch.IsResolved, OrganizationID: orgID, CreatedAt: now, UpdatedAt: now, Version: 1,
+	}
+	s.m.Incidents[i.ID] = i
+	s.m.Links[i.ID] = s.m.link(orgID, ch.ServiceIDs)
+	return i.ID, nil
+}
+
+func (s incidents) Update(ctx context.Context, orgID, id string, ch store.IncidentChange, version int) (models.Incident, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	i := s.m.incident(orgID, id)
+	if i == nil {
+		return models.Incident{}, sql.ErrNoRows
+	}
+	if version != 0 && version != i.Version {
+		return models.Incident{}, store.ErrVersionConflict
+	}
+	prev := *i
+	i.Title, i.Description, i.Type, i.Status, i.IsResolved = ch.Title, ch.Description, ch.Type, ch.Status, ch.IsResolved
+	i.UpdatedAt = time.Now()
+	i.Version++
+	links := s.m.link(orgID, ch.ServiceIDs)
+	for _, sid := range s.m.Links[id] {
+		if s.m.service(orgID, sid) == nil && !slices.Contains(links, sid) {
+			links = append(links, sid) // archived services stay linked
+		}
+	}
+	s.m.Links[id] = links
+	return prev, nil
+}
+
+func (s incidents) Archive(ctx context.Context, orgID, id string) (models.Incident, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	i := s.m.incident(orgID, id)
+	if i == nil {
+		return models.Incident{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	i.ArchivedAt = &now
+	i.Version++
+	return s.m.load(i), nil
+}
+
+func (s incidents) Restore(ctx context.Context, orgID, id string) (models.Incident, time.Time, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	i := s.m.Incidents[id]
+	if i == nil || i.OrganizationID != orgID || i.ArchivedAt == nil {
+		return models.Incident{}, time.Time{}, sql.ErrNoRows
+	}
+	archivedAt := *i.ArchivedAt
+	i.ArchivedAt = nil
+	i.Version++
+	return s.m.load(i), archivedAt, nil
+}
+
+func (s incidents) ServiceIDs(ctx context.Context, id string) ([]string, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	return append([]string(nil), s.m.Links[id]...), nil
+}
+
+type updates struct {
+	store.UpdateStore
+	m *Memory
+}
+
+func (s updates) Add(ctx context.Context, orgID, incidentID, message string) (models.IncidentUpdate, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	if s.m.incident(orgID, incidentID) == nil {
+		return models.IncidentUpdate{}, sql.ErrNoRows
+	}
+	u := models.IncidentUpdate{ID: uuid.NewString(), IncidentID: incidentID, Message: message, CreatedAt: time.Now()}
+	s.m.Updates = append(s.m.Updates, u)
+	return u, nil
+}
+
+type audit struct {
+	store.AuditStore
+	m *Memory
+}
+
+func (s audit) Record(ctx context.Context, e models.AuditEntry) error {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	s.m.Audit = append(s.m.Audit, e)
+	return nil
+}
+
+// List returns the org's matching entries, most recent first, on a single page.
+func (s audit) List(ctx context.Context, q store.AuditQuery) ([]models.AuditEntry, string, error) {
+	s.m.lock.Lock()
+	defer s.m.lock.Unlock()
+	var entries []models.AuditEntry
+	for _, e := range slices.Backward(s.m.Audit) {
+		if e.OrganizationID == q.OrgID && (q.Action == "" || e.Action == q.Action) &&
+			(q.TargetType == "" || e.TargetType == q.TargetType) && (q.TargetID == "" || e.TargetID == q.TargetID) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, "", nil
+}
@@ -3,20 +3,28 @@ package utils
 import (
 	"fmt"
 	"net/smtp"
-	"os"
+	"strconv"
+
+	"backend-go/config"
 )
 
-// SendEmail sends an email using SMTP credentials from environment variables
+var smtpConfig config.SMTP
+
+// UseSMTP sets the SMTP server and credentials SendEmail uses.
+func UseSMTP(cfg config.SMTP) {
+	smtpConfig = cfg
+}
+
+// SendEmail sends an email through the SMTP server set by UseSMTP
 func SendEmail(to []string, subject, body string) error {
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	smtpPass := os.Getenv("SMTP_PASS")
-	sender := os.Getenv("SMTP_SENDER")
-
-	if smtpHost == "" || smtpPort == "" || smtpUser == "" || smtpPass == "" || sender == "" {
-		return fmt.Errorf("SMTP environment variables not set")
+	if !smtpConfig.Enabled() {
+		return fmt.Errorf("SMTP not configured")
 	}
+	smtpHost := smtpConfig.Host
+	smtpPort := strconv.Itoa(smtpConfig.Port)
+	smtpUser := smtpConfig.User
+	smtpPass := smtpConfig.Pass
+	sender := smtpConfig.Sender
 
 	header := make(map[string]string)
 	header["From"] = sender
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath evaluates a simple JSONPath expression against a decoded JSON document.
+// Supported syntax is the root "$", dotted keys ("$.alert.name"), bracketed keys
+// ("$['rule name']") and array indexes ("$.alerts[0].status"). Keys only select
+// object members and indexes only select array elements, so $['0'] is a key.
+func JSONPath(doc interface{}, path string) (interface{}, bool) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+	cur := doc
+	for _, step := range steps {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			if step.index {
+				return nil, false
+			}
+			v, ok := node[step.key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []interface{}:
+			if !step.index {
+				return nil, false
+			}
+			i, err := strconv.Atoi(step.key)
+			if err != nil || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// ValidateJSONPath reports whether path uses the syntax JSONPath understands.
+func ValidateJSONPath(path string) error {
+	_, err := parseJSONPath(path)
+	return err
+}
+
+// JSONPathString is JSONPath with the result rendered as a string; numbers and
+// booleans are formatted, objects and arrays are not considered a match.
+func JSONPathString(doc interface{}, path string) (string, bool) {
+	v, ok := JSONPath(doc, path)
+	if !ok {
+		return "", false
+	}
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	return "", false
+}
+
+// pathStep is an object key, or an array index when index is set.
+type pathStep struct {
+	key   string
+	index bool
+}
+
+func parseJSONPath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in path %q", path)
+			}
+			steps = append(steps, pathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in path %q", path)
+			}
+			key := rest[1:end]
+			switch {
+			case len(key) >= 2 && (key[0] == '\'' || key[0] == '"') && key[len(key)-1] == key[0]:
+				steps = append(steps, pathStep{key: key[1 : len(key)-1]})
+			case isDigits(key):
+				steps = append(steps, pathStep{key: key, index: true})
+			default:
+				return nil, fmt.Errorf("invalid index %q in path %q", key, path)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %q", rest[0], path)
+		}
+	}
+	return steps, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONPathString(t *testing.T) {
+	var doc interface{}
+	err := json.Unmarshal([]byte(`{
+		"alert": {"name": "disk", "labels": {"rule name": "r1"}},
+		"alerts": [{"status": "firing"}, {"status": "ok"}],
+		"count": 3, "up": true, "ratio": 0.5, "none": null,
+		"nested": [[1, 2]]
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"$.alert.name", "disk", true},
+		{"$['alert']['name']", "disk", true},
+		{`$["alert"].labels['rule name']`, "r1", true},
+		{"$.alerts[1].status", "ok", true},
+		{"$.nested[0][1]", "2", true},
+		{"$.count", "3", true},
+		{"$.up", "true", true},
+		{"$.ratio", "0.5", true},
+		{"$.alerts[2].status", "", false},
+		{"$.alert", "", false},
+		{"$.alerts", "", false},
+		{"$.none", "", false},
+		{"$.missing", "", false},
+		{"$.alert.name.first", "", false},
+		{"$.count[0]", "", false},
+		{"$.alerts['0'].status", "", false},
+		{"$.alerts.0.status", "", false},
+		{"$.alert[0]", "", false},
+		{"$['alerts'][0]['status']", "firing", true},
+		{"$", "", false},
+		{"alert.name", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := JSONPathString(doc, tc.path)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("JSONPathString(%q) = %q, %v; want %q, %v", tc.path, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestJSONPathRoot(t *testing.T) {
+	if v, ok := JSONPath("up", "$"); !ok || v != "up" {
+		t.Errorf(`JSONPath("up", "$") = %v, %v`, v, ok)
+	}
+}
+
+func TestValidateJSONPath(t *testing.T) {
+	for _, path := range []string{"$", "$.a", "$.a.b", "$['a b']", `$["a"]`, "$[0]", "$.a[10].b"} {
+		if err := ValidateJSONPath(path); err != nil {
+			t.Errorf("ValidateJSONPath(%q): %v", path, err)
+		}
+	}
+	for _, path := range []string{"", "a.b", "$.", "$..a", "$.a.", "$a", "$[", "$.a[0", "$[]", "$[x]", "$['a]", "$['a\"]", "$[-1]", "$[+1]", "$[1.5]", "$[ 1]"} {
+		if err := ValidateJSONPath(path); err == nil {
+			t.Errorf("ValidateJSONPath(%q) accepted a malformed path", path)
+		}
+	}
+}
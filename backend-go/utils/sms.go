@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend-go/config"
+)
+
+// SMSProvider sends text messages and places voice calls to phone numbers in E.164 format.
+type SMSProvider interface {
+	SendSMS(to, body string) error
+	Call(to, message string) error
+}
+
+// TwilioProvider talks to the Twilio REST API, or to any stub exposing the same shape.
+type TwilioProvider struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+	Client     *http.Client
+}
+
+// NewSMSProvider returns nil when SMS is not configured.
+func NewSMSProvider(cfg config.SMS) SMSProvider {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &TwilioProvider{
+		BaseURL:    strings.TrimRight(cfg.APIURL, "/"),
+		AccountSID: cfg.AccountSID,
+		AuthToken:  cfg.AuthToken,
+		From:       cfg.From,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *TwilioProvider) SendSMS(to, body string) error {
+	return p.post("Messages.json", url.Values{"To": {to}, "From": {p.From}, "Body": {body}})
+}
+
+func (p *TwilioProvider) Call(to, message string) error {
+	var said bytes.Buffer
+	if err := xml.EscapeText(&said, []byte(message)); err != nil {
+		return err
+	}
+	twiml := "<Response><Say>" + said.String() + "</Say></Response>"
+	return p.post("Calls.json", url.Values{"To": {to}, "From": {p.From}, "Twiml": {twiml}})
+}
+
+func (p *TwilioProvider) post(resource string, form url.Values) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/%s", p.BaseURL, url.PathEscape(p.AccountSID), resource)
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.AccountSID, p.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
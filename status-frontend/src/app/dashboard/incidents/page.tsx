@@ -11,6 +11,7 @@ import { toast } from 'sonner';
 import { Badge } from '@/components/ui/badge';
 import Link from 'next/link';
 import { Navbar } from '@/components/navbar'
+import { fetchAllPages, versionTag, writeError } from '@/lib/api';
 
 const API = (process.env.NEXT_PUBLIC_API_BASE_URL || 'http://localhost:8080') + '/api';
 
@@ -31,6 +32,7 @@ interface Incident {
   organizationId: string;
   createdAt: string;
   updatedAt: string;
+  version: number;
   services: Service[];
   updates: { id: string; message: string; createdAt: string }[];
 }
@@ -68,15 +70,12 @@ const IncidentsPage = () => {
         organizationId: organization.id,
       });
       if (!token) throw new Error('No token');
-      const [incidentsRes, servicesRes] = await Promise.all([
-        fetch(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } }),
-        fetch(`${API}/services`, { headers: { Authorization: `Bearer ${token}` } }),
+      const [incidentsData, servicesData] = await Promise.all([
+        fetchAllPages<Incident>(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } }),
+        fetchAllPages<Service>(`${API}/services`, { headers: { Authorization: `Bearer ${token}` } }),
       ]);
-      if (!incidentsRes.ok || !servicesRes.ok) throw new Error('Failed to fetch data');
-      const incidentsData = await incidentsRes.json();
-      const servicesData = await servicesRes.json();
-      setIncidents(Array.isArray(incidentsData) ? incidentsData : []);
-      setServices(Array.isArray(servicesData) ? servicesData : []);
+      setIncidents(incidentsData);
+      setServices(servicesData);
     } catch (err: any) {
       setError(err.message || 'Unknown error');
     } finally {
@@ -91,31 +90,46 @@ const IncidentsPage = () => {
 
   useEffect(() => {
     if (!organization) return;
-    // SSE connection for real-time updates
-    const sse = new EventSource(`${API}/stream`, { withCredentials: true });
-    sse.onmessage = (event) => {
-      try {
-        const data = JSON.parse(event.data);
-        let msg = '';
-        if (data.event === 'service_created') msg = 'A service was created.';
-        else if (data.event === 'service_updated') msg = 'A service was updated.';
-        else if (data.event === 'service_deleted') msg = 'A service was deleted.';
-        else if (data.event === 'incident_created') msg = 'A new incident was created.';
-        else if (data.event === 'incident_updated') msg = 'An incident was updated.';
-        else if (data.event === 'incident_update_added') msg = 'An incident update was added.';
-        if (msg) toast.info(msg);
-      } catch {}
-      // Always refresh data
-      fetchData();
-    };
-    sse.onerror = () => {
-      sse.close();
-      toast.error('Lost real-time connection. Trying to reconnect...');
-      // Optionally, try to reconnect after a delay
-      setTimeout(() => window.location.reload(), 3000);
+    let sse: EventSource | null = null;
+    let cancelled = false;
+    const messages: Record<string, string> = {
+      service_created: 'A service was created.',
+      service_updated: 'A service was updated.',
+      service_archived: 'A service was deleted.',
+      service_restored: 'A service was restored.',
+      incident_created: 'A new incident was created.',
+      incident_updated: 'An incident was updated.',
+      incident_update_added: 'An incident update was added.',
+      incident_archived: 'An incident was deleted.',
+      incident_restored: 'An incident was restored.',
     };
+    // SSE connection for real-time updates (org-scoped, token passed as query param)
+    (async () => {
+      const token = await getToken({
+        template: 'status_jwt',
+        organizationId: organization.id,
+      });
+      if (!token || cancelled) return;
+      sse = new EventSource(`${API}/stream?token=${encodeURIComponent(token)}`);
+      Object.entries(messages).forEach(([type, msg]) => {
+        sse?.addEventListener(type, () => {
+          toast.info(msg);
+          // Always refresh data
+          fetchData();
+        });
+      });
+      // Sent when events were missed during a reconnect and cannot be replayed
+      sse.addEventListener('resync', () => fetchData());
+      sse.onerror = () => {
+        sse?.close();
+        toast.error('Lost real-time connection. Trying to reconnect...');
+        // Optionally, try to reconnect after a delay
+        setTimeout(() => window.location.reload(), 3000);
+      };
+    })();
     return () => {
-      sse.close();
+      cancelled = true;
+      sse?.close();
     };
   // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [organization]);
@@ -147,9 +161,7 @@ const IncidentsPage = () => {
       setAddOpen(false);
       toast.success('Incident created!');
       // Refresh incidents
-      const incidentsRes = await fetch(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } });
-      const incidentsData = await incidentsRes.json();
-      setIncidents(Array.isArray(incidentsData) ? incidentsData : []);
+      setIncidents(await fetchAllPages<Incident>(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } }));
     } catch (err: any) {
       setAddError(err.message || 'Unknown error');
       toast.error('Failed to create incident');
@@ -180,6 +192,7 @@ const IncidentsPage = () => {
         headers: {
           'Content-Type': 'application/json',
           Authorization: `Bearer ${token}`,
+          ...(selectedIncident ? { 'If-Match': versionTag(selectedIncident.version) } : {}),
         },
         body: JSON.stringify({
           title: data.title,
@@ -190,14 +203,12 @@ const IncidentsPage = () => {
           isResolved: selectedIncident?.isResolved || false,
         }),
       });
-      if (!res.ok) throw new Error('Failed to update incident');
+      if (!res.ok) throw writeError(res, 'Failed to update incident');
       setEditOpen(false);
       setSelectedIncident(null);
       toast.success('Incident updated!');
       // Refresh incidents
-      const incidentsRes = await fetch(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } });
-      const incidentsData = await incidentsRes.json();
-      setIncidents(Array.isArray(incidentsData) ? incidentsData : []);
+      setIncidents(await fetchAllPages<Incident>(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } }));
     } catch (err: any) {
       setEditError(err.message || 'Unknown error');
       toast.error('Failed to update incident');
@@ -217,28 +228,20 @@ const IncidentsPage = () => {
       });
       if (!token) throw new Error('No token');
       const res = await fetch(`${API}/incidents/${selectedIncident.id}`, {
-        method: 'PUT',
+        method: 'PATCH',
         headers: {
-          'Content-Type': 'application/json',
+          'Content-Type': 'application/merge-patch+json',
           Authorization: `Bearer ${token}`,
+          'If-Match': versionTag(selectedIncident.version),
         },
-        body: JSON.stringify({
-          title: selectedIncident.title,
-          description: selectedIncident.description,
-          type: selectedIncident.type,
-          status: selectedIncident.status,
-          serviceIds: selectedIncident.services.map(s => s.id),
-          isResolved: true,
-        }),
+        body: JSON.stringify({ isResolved: true }),
       });
-      if (!res.ok) throw new Error('Failed to resolve incident');
+      if (!res.ok) throw writeError(res, 'Failed to resolve incident');
       setResolveOpen(false);
       setSelectedIncident(null);
       toast.success('Incident resolved!');
       // Refresh incidents
-      const incidentsRes = await fetch(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } });
-      const incidentsData = await incidentsRes.json();
-      setIncidents(Array.isArray(incidentsData) ? incidentsData : []);
+      setIncidents(await fetchAllPages<Incident>(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } }));
     } catch (err: any) {
       setResolveError(err.message || 'Unknown error');
       toast.error('Failed to resolve incident');
@@ -271,9 +274,7 @@ const IncidentsPage = () => {
       setUpdateMessage('');
       toast.success('Update added!');
       // Refresh incidents
-      const incidentsRes = await fetch(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } });
-      const incidentsData = await incidentsRes.json();
-      setIncidents(Array.isArray(incidentsData) ? incidentsData : []);
+      setIncidents(await fetchAllPages<Incident>(`${API}/incidents`, { headers: { Authorization: `Bearer ${token}` } }));
     } catch (err: any) {
       setUpdateError(err.message || 'Unknown error');
       toast.error('Failed to add update');
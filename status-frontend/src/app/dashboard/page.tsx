@@ -14,6 +14,7 @@ import { ServiceDialog } from '@/components/service-dialog'
 import { Dialog, DialogContent, DialogHeader, DialogTitle } from '@/components/ui/dialog'
 
 import { Navbar } from '@/components/navbar'
+import { fetchAllPages, versionTag, writeError } from '@/lib/api'
 
 const API = (process.env.NEXT_PUBLIC_API_BASE_URL || 'http://localhost:8080') + '/api'
 
@@ -52,12 +53,10 @@ export default function Dashboard() {
         organizationId: organization?.id,
       })
       if (!token) throw new Error('No token')
-      const res = await fetch(`${API}/services`, {
+      const data = await fetchAllPages<Service>(`${API}/services`, {
         headers: { Authorization: `Bearer ${token}` },
       })
-      if (!res.ok) throw new Error('Failed to fetch services')
-      const data = await res.json()
-      setServices(Array.isArray(data) ? data : [])
+      setServices(data)
     } catch (e: any) {
       setError(e.message || 'Unknown error')
     } finally {
@@ -114,10 +113,11 @@ export default function Dashboard() {
         headers: {
           'Content-Type': 'application/json',
           Authorization: `Bearer ${token}`,
+          'If-Match': versionTag(selected.version),
         },
         body: JSON.stringify(input),
       })
-      if (!res.ok) throw new Error('Failed to update service')
+      if (!res.ok) throw writeError(res, 'Failed to update service')
       setEditOpen(false)
       setSelected(null)
       fetchServices()
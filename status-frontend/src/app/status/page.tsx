@@ -6,6 +6,7 @@ import { Card } from '@/components/ui/card';
 import Link from 'next/link';
 import { Button } from '@/components/ui/button';
 import { Navbar } from '@/components/navbar'
+import { fetchAllPages, fetchPage } from '@/lib/api';
 
 const API = (process.env.NEXT_PUBLIC_API_BASE_URL || 'http://localhost:8080') + '/api/public';
 
@@ -39,15 +40,13 @@ const StatusPage = () => {
     setLoading(true);
     setError(null);
     try {
-      const [servicesRes, incidentsRes] = await Promise.all([
-        fetch(`${API}/services`),
-        fetch(`${API}/incidents`),
+      // every service, and the most recent page of incidents
+      const [servicesData, incidentsPage] = await Promise.all([
+        fetchAllPages<Service>(`${API}/services`),
+        fetchPage<Incident>(`${API}/incidents?limit=50`),
       ]);
-      if (!servicesRes.ok || !incidentsRes.ok) throw new Error('Failed to fetch data');
-      const servicesData = await servicesRes.json();
-      const incidentsData = await incidentsRes.json();
-      setServices(Array.isArray(servicesData) ? servicesData : []);
-      setIncidents(Array.isArray(incidentsData) ? incidentsData : []);
+      setServices(servicesData);
+      setIncidents(incidentsPage.items);
     } catch (err: any) {
       setError(err.message || 'Unknown error');
     } finally {
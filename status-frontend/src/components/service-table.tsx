@@ -20,6 +20,7 @@ export interface Service {
   name: string;
   status: ServiceStatus;
   organizationId: string;
+  version: number;
 }
 
 type Props = {
@@ -0,0 +1,39 @@
+export interface Page<T> {
+  items: T[];
+  next_cursor: string | null;
+}
+
+// Entity tag for a service or incident version, sent as If-Match so a write fails with
+// 412 instead of overwriting someone else's change.
+export function versionTag(version: number): string {
+  return `"${version}"`;
+}
+
+// Error message for a failed write, explaining 412 conflicts.
+export function writeError(res: Response, fallback: string): Error {
+  if (res.status === 412) {
+    return new Error('Someone else changed this in the meantime. Reload and try again.');
+  }
+  return new Error(fallback);
+}
+
+// Fetches one page of a paginated listing (GET /services, /incidents and their public variants).
+export async function fetchPage<T>(url: string, init?: RequestInit): Promise<Page<T>> {
+  const res = await fetch(url, init);
+  if (!res.ok) throw new Error('Failed to fetch data');
+  const page = await res.json();
+  return { items: Array.isArray(page?.items) ? page.items : [], next_cursor: page?.next_cursor ?? null };
+}
+
+// Follows next_cursor until the listing is exhausted.
+export async function fetchAllPages<T>(url: string, init?: RequestInit): Promise<T[]> {
+  const items: T[] = [];
+  let cursor: string | null = null;
+  do {
+    const sep = url.includes('?') ? '&' : '?';
+    const page: Page<T> = await fetchPage<T>(cursor ? `${url}${sep}cursor=${encodeURIComponent(cursor)}` : url, init);
+    items.push(...page.items);
+    cursor = page.next_cursor;
+  } while (cursor);
+  return items;
+}